@@ -31,6 +31,11 @@ func CreateRunCommand() *cli.Command {
 				Usage:   "任务配置文件路径(.yaml)",
 				Value:   "tasks.yaml",
 			},
+			&cli.StringSliceFlag{
+				Name:    "library",
+				Aliases: []string{"l"},
+				Usage:   "过程库文件路径(.yaml)，可指定多个",
+			},
 		},
 		Action: executeRunCommand,
 	}
@@ -75,7 +80,7 @@ func executeRunCommand(c *cli.Context) error {
 	}
 
 	// 加载任务
-	tasks, err := operator.LoadTasksFromFile(tasksFile)
+	tasks, err := operator.LoadTasksFromFile(tasksFile, c.StringSlice("library")...)
 	if err != nil {
 		return fmt.Errorf("加载任务失败: %w (文件: %s)", err, tasksFile)
 	}
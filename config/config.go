@@ -12,22 +12,23 @@ import (
 // Config 定义应用程序配置
 type Config struct {
 	Browser BrowserConfig `mapstructure:"browser" json:"browser"`
-	Tasks   TasksConfig  `mapstructure:"tasks" json:"tasks"`
+	Tasks   TasksConfig   `mapstructure:"tasks" json:"tasks"`
 	Logging LoggingConfig `mapstructure:"logging" json:"logging"`
 }
 
 // BrowserConfig 浏览器配置
 type BrowserConfig struct {
-	Headless     bool   `mapstructure:"headless" json:"headless"`
-	UserAgent    string `mapstructure:"user_agent" json:"user_agent"`
-	Timeout      int    `mapstructure:"timeout" json:"timeout"`
+	Headless       bool   `mapstructure:"headless" json:"headless"`
+	UserAgent      string `mapstructure:"user_agent" json:"user_agent"`
+	Timeout        int    `mapstructure:"timeout" json:"timeout"`
 	ExecutablePath string `mapstructure:"executable_path" json:"executable_path"`
 }
 
 // TasksConfig 任务配置
 type TasksConfig struct {
-	DefaultWaitTime int  `mapstructure:"default_wait_time" json:"default_wait_time"`
-	AutoScreenshot  bool `mapstructure:"auto_screenshot" json:"auto_screenshot"`
+	DefaultWaitTime int    `mapstructure:"default_wait_time" json:"default_wait_time"`
+	AutoScreenshot  bool   `mapstructure:"auto_screenshot" json:"auto_screenshot"`
+	DownloadsDir    string `mapstructure:"downloads_dir" json:"downloads_dir"` // download操作保存文件的目录
 }
 
 // LoggingConfig 日志配置
@@ -41,14 +42,15 @@ type LoggingConfig struct {
 func DefaultConfig() *Config {
 	return &Config{
 		Browser: BrowserConfig{
-			Headless:      true,
-			UserAgent:     "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
-			Timeout:       30,
+			Headless:       true,
+			UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+			Timeout:        30,
 			ExecutablePath: "",
 		},
 		Tasks: TasksConfig{
 			DefaultWaitTime: 5,
 			AutoScreenshot:  true,
+			DownloadsDir:    "downloads",
 		},
 		Logging: LoggingConfig{
 			Level:   "info",
@@ -71,7 +73,7 @@ func LoadConfig(configPath string) (*Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("获取用户目录失败: %w", err)
 		}
-		
+
 		configDir := filepath.Join(homeDir, ".auto-go")
 		viper.AddConfigPath(configDir)
 		viper.SetConfigName("config")
@@ -114,7 +116,7 @@ func SaveConfig(config *Config, configPath string) error {
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	
+
 	if err := encoder.Encode(config); err != nil {
 		return fmt.Errorf("编码配置文件失败: %w", err)
 	}
@@ -134,10 +136,10 @@ func ValidateConfig(config *Config) error {
 	if config.Browser.Timeout <= 0 {
 		return fmt.Errorf("浏览器超时时间必须大于0")
 	}
-	
+
 	if config.Tasks.DefaultWaitTime < 0 {
 		return fmt.Errorf("默认等待时间不能为负数")
 	}
-	
+
 	return nil
-}
\ No newline at end of file
+}
@@ -55,6 +55,7 @@ func (a *Automation) setupBrowser() (*operator.BrowserManager, error) {
 	logger.BrowserStart(a.Headless, a.ChromePath)
 
 	bm := operator.NewBrowserManager()
+	bm.DownloadsDir = a.Config.Tasks.DownloadsDir
 	if err := bm.LaunchWithExecutable(a.Headless, a.ChromePath); err != nil {
 		return nil, fmt.Errorf("启动浏览器失败: %w", err)
 	}
@@ -0,0 +1,11 @@
+package logger
+
+// AttemptRecord 重试策略下单次尝试的执行记录，随任务结果一起保存
+type AttemptRecord struct {
+	Target  string `json:"target"`          // 尝试执行的对象
+	Attempt int    `json:"attempt"`         // 第几次尝试
+	Success bool   `json:"success"`         // 是否成功
+	Kind    string `json:"kind,omitempty"`  // 错误类型
+	Error   string `json:"error,omitempty"` // 错误信息
+	Time    string `json:"time"`            // 尝试时间
+}
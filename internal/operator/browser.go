@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
@@ -14,6 +17,10 @@ type BrowserManager struct {
 	Browser playwright.Browser
 	Page    playwright.Page
 	Context playwright.BrowserContext
+
+	DownloadsDir string // 下载文件的保存目录，为空时使用DefaultDownloadsDir
+
+	ownsContext bool // 会话是否独占浏览器上下文，关闭会话时一并关闭
 }
 
 // NewBrowserManager 创建新的浏览器管理器
@@ -61,14 +68,9 @@ func (bm *BrowserManager) LaunchWithExecutable(headless bool, executablePath str
 	bm.Browser = browser
 
 	// 创建浏览器上下文
-	context, err := browser.NewContext(playwright.BrowserNewContextOptions{
-		Viewport: &playwright.Size{
-			Width:  1920,
-			Height: 1080,
-		},
-	})
+	context, err := bm.newBrowserContext()
 	if err != nil {
-		return fmt.Errorf("创建浏览器上下文失败: %w", err)
+		return err
 	}
 
 	bm.Context = context
@@ -83,22 +85,175 @@ func (bm *BrowserManager) LaunchWithExecutable(headless bool, executablePath str
 	return nil
 }
 
+// newBrowserContext 创建浏览器上下文
+func (bm *BrowserManager) newBrowserContext() (playwright.BrowserContext, error) {
+	context, err := bm.Browser.NewContext(playwright.BrowserNewContextOptions{
+		Viewport: &playwright.Size{
+			Width:  1920,
+			Height: 1080,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建浏览器上下文失败: %w", err)
+	}
+	return context, nil
+}
+
+// NewSession 基于同一浏览器创建新的会话，isolated为true时使用独立的浏览器上下文（Cookie和存储互不共享），否则在当前上下文中打开新页面
+func (bm *BrowserManager) NewSession(isolated bool) (*BrowserManager, error) {
+	if bm.Browser == nil || bm.Context == nil {
+		return nil, fmt.Errorf("浏览器未启动")
+	}
+
+	session := &BrowserManager{Browser: bm.Browser, Context: bm.Context, DownloadsDir: bm.DownloadsDir}
+	if isolated {
+		context, err := bm.newBrowserContext()
+		if err != nil {
+			return nil, err
+		}
+		session.Context = context
+		session.ownsContext = true
+	}
+
+	page, err := session.Context.NewPage()
+	if err != nil {
+		if session.ownsContext {
+			session.Context.Close()
+		}
+		return nil, fmt.Errorf("创建页面失败: %w", err)
+	}
+	session.Page = page
+	return session, nil
+}
+
+// CloseSession 关闭NewSession创建的页面，独立上下文会一并关闭
+func (bm *BrowserManager) CloseSession() error {
+	if bm.ownsContext {
+		return bm.Context.Close()
+	}
+	if bm.Page != nil {
+		return bm.Page.Close()
+	}
+	return nil
+}
+
 // Navigate 导航到指定URL
 func (bm *BrowserManager) Navigate(url string) error {
+	return bm.Goto(url, WaitUntilNetworkIdle, 0)
+}
+
+// Goto 导航到指定URL，相对地址基于当前页面解析；waitUntil为导航完成的判断条件，timeout为0时使用Playwright的默认超时
+func (bm *BrowserManager) Goto(address, waitUntil string, timeout time.Duration) error {
 	if bm.Page == nil {
 		return fmt.Errorf("页面未初始化")
 	}
+	state, err := waitUntilState(waitUntil)
+	if err != nil {
+		return err
+	}
+
+	if base, err := url.Parse(bm.Page.URL()); err == nil && (base.Scheme == "http" || base.Scheme == "https") {
+		if ref, err := url.Parse(address); err == nil {
+			address = base.ResolveReference(ref).String()
+		}
+	}
 
-	_, err := bm.Page.Goto(url, playwright.PageGotoOptions{
-		WaitUntil: playwright.WaitUntilStateNetworkidle,
+	_, err = bm.Page.Goto(address, playwright.PageGotoOptions{
+		WaitUntil: state,
+		Timeout:   navigationTimeout(timeout),
 	})
 	if err != nil {
-		return fmt.Errorf("导航到 %s 失败: %w", url, err)
+		return fmt.Errorf("导航到 %s 失败: %w", address, err)
 	}
 
 	return nil
 }
 
+// Reload 刷新当前页面
+func (bm *BrowserManager) Reload(waitUntil string, timeout time.Duration) error {
+	if bm.Page == nil {
+		return fmt.Errorf("页面未初始化")
+	}
+	state, err := waitUntilState(waitUntil)
+	if err != nil {
+		return err
+	}
+
+	_, err = bm.Page.Reload(playwright.PageReloadOptions{
+		WaitUntil: state,
+		Timeout:   navigationTimeout(timeout),
+	})
+	if err != nil {
+		return fmt.Errorf("刷新页面失败: %w", err)
+	}
+
+	return nil
+}
+
+// GoBack 后退到浏览历史中的上一页
+func (bm *BrowserManager) GoBack(waitUntil string, timeout time.Duration) error {
+	if bm.Page == nil {
+		return fmt.Errorf("页面未初始化")
+	}
+	state, err := waitUntilState(waitUntil)
+	if err != nil {
+		return err
+	}
+
+	_, err = bm.Page.GoBack(playwright.PageGoBackOptions{
+		WaitUntil: state,
+		Timeout:   navigationTimeout(timeout),
+	})
+	if err != nil {
+		return fmt.Errorf("后退失败: %w", err)
+	}
+
+	return nil
+}
+
+// GoForward 前进到浏览历史中的下一页
+func (bm *BrowserManager) GoForward(waitUntil string, timeout time.Duration) error {
+	if bm.Page == nil {
+		return fmt.Errorf("页面未初始化")
+	}
+	state, err := waitUntilState(waitUntil)
+	if err != nil {
+		return err
+	}
+
+	_, err = bm.Page.GoForward(playwright.PageGoForwardOptions{
+		WaitUntil: state,
+		Timeout:   navigationTimeout(timeout),
+	})
+	if err != nil {
+		return fmt.Errorf("前进失败: %w", err)
+	}
+
+	return nil
+}
+
+// PageInfo 获取当前页面的地址和标题
+func (bm *BrowserManager) PageInfo() (string, string, error) {
+	if bm.Page == nil {
+		return "", "", fmt.Errorf("页面未初始化")
+	}
+
+	title, err := bm.Page.Title()
+	if err != nil {
+		return "", "", fmt.Errorf("获取页面标题失败: %w", err)
+	}
+
+	return bm.Page.URL(), title, nil
+}
+
+// navigationTimeout 转换导航超时时间，为0时使用Playwright的默认超时
+func navigationTimeout(timeout time.Duration) *float64 {
+	if timeout <= 0 {
+		return nil
+	}
+	return playwright.Float(float64(timeout.Milliseconds()))
+}
+
 // WaitForSelector 等待选择器出现
 func (bm *BrowserManager) WaitForSelector(selector string, timeout time.Duration) error {
 	if bm.Page == nil {
@@ -160,6 +315,160 @@ func (bm *BrowserManager) Click(selector string) error {
 	return nil
 }
 
+// TypeText 逐个字符输入文本，delay为每个字符之间的间隔；selector不为空时先聚焦该元素，否则输入到当前焦点元素
+func (bm *BrowserManager) TypeText(selector, text string, delay time.Duration) error {
+	if bm.Page == nil {
+		return fmt.Errorf("页面未初始化")
+	}
+
+	var err error
+	if selector != "" {
+		if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+			return fmt.Errorf("等待输入元素 %s 失败: %w", selector, err)
+		}
+		err = bm.Page.Locator(selector).PressSequentially(text, playwright.LocatorPressSequentiallyOptions{
+			Delay: playwright.Float(float64(delay.Milliseconds())),
+		})
+	} else {
+		err = bm.Page.Keyboard().Type(text, playwright.KeyboardTypeOptions{
+			Delay: playwright.Float(float64(delay.Milliseconds())),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("输入文本失败: %w", err)
+	}
+
+	log.Printf("⌨️ 已输入文本: %s", text)
+	return nil
+}
+
+// PressKey 按下并松开按键或组合键，如 Enter、Control+A；selector不为空时先聚焦该元素
+func (bm *BrowserManager) PressKey(selector, key string) error {
+	if bm.Page == nil {
+		return fmt.Errorf("页面未初始化")
+	}
+
+	var err error
+	if selector != "" {
+		if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+			return fmt.Errorf("等待按键元素 %s 失败: %w", selector, err)
+		}
+		err = bm.Page.Locator(selector).Press(key)
+	} else {
+		err = bm.Page.Keyboard().Press(key)
+	}
+	if err != nil {
+		return fmt.Errorf("按键 %s 失败: %w", key, err)
+	}
+
+	log.Printf("⌨️ 已按键: %s", key)
+	return nil
+}
+
+// KeyDown 按住按键不放，selector不为空时先聚焦该元素
+func (bm *BrowserManager) KeyDown(selector, key string) error {
+	if err := bm.focus(selector); err != nil {
+		return err
+	}
+	if err := bm.Page.Keyboard().Down(key); err != nil {
+		return fmt.Errorf("按下按键 %s 失败: %w", key, err)
+	}
+
+	log.Printf("⌨️ 已按下按键: %s", key)
+	return nil
+}
+
+// KeyUp 松开按键，selector不为空时先聚焦该元素
+func (bm *BrowserManager) KeyUp(selector, key string) error {
+	if err := bm.focus(selector); err != nil {
+		return err
+	}
+	if err := bm.Page.Keyboard().Up(key); err != nil {
+		return fmt.Errorf("松开按键 %s 失败: %w", key, err)
+	}
+
+	log.Printf("⌨️ 已松开按键: %s", key)
+	return nil
+}
+
+// focus 聚焦元素，selector为空时保持当前焦点
+func (bm *BrowserManager) focus(selector string) error {
+	if bm.Page == nil {
+		return fmt.Errorf("页面未初始化")
+	}
+	if selector == "" {
+		return nil
+	}
+
+	if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+		return fmt.Errorf("等待聚焦元素 %s 失败: %w", selector, err)
+	}
+	if err := bm.Page.Locator(selector).Focus(); err != nil {
+		return fmt.Errorf("聚焦元素 %s 失败: %w", selector, err)
+	}
+	return nil
+}
+
+// UploadFiles 为文件输入框设置要上传的文件
+func (bm *BrowserManager) UploadFiles(selector string, files []string) error {
+	if bm.Page == nil {
+		return fmt.Errorf("页面未初始化")
+	}
+
+	if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+		return fmt.Errorf("等待文件输入框 %s 失败: %w", selector, err)
+	}
+
+	if err := bm.Page.Locator(selector).SetInputFiles(files); err != nil {
+		return fmt.Errorf("设置上传文件失败: %w", err)
+	}
+
+	log.Printf("📤 已设置上传文件: %s = %v", selector, files)
+	return nil
+}
+
+// Download 点击触发元素并等待下载完成，将文件保存到下载目录并返回保存的路径；
+// saveAs为保存的文件名（相对于下载目录），为空时使用服务器建议的文件名；timeout为0时使用Playwright的默认超时
+func (bm *BrowserManager) Download(selector, saveAs string, timeout time.Duration) (string, error) {
+	if bm.Page == nil {
+		return "", fmt.Errorf("页面未初始化")
+	}
+
+	if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+		return "", fmt.Errorf("等待下载元素 %s 失败: %w", selector, err)
+	}
+
+	download, err := bm.Page.ExpectDownload(func() error {
+		return bm.Page.Click(selector)
+	}, playwright.PageExpectDownloadOptions{Timeout: navigationTimeout(timeout)})
+	if err != nil {
+		return "", fmt.Errorf("等待下载失败: %w", err)
+	}
+
+	dir := bm.DownloadsDir
+	if dir == "" {
+		dir = DefaultDownloadsDir
+	}
+	if saveAs == "" {
+		saveAs = download.SuggestedFilename()
+	}
+	path, err := downloadPath(dir, saveAs)
+	if err != nil {
+		download.Cancel()
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("创建下载目录失败: %w", err)
+	}
+
+	if err := download.SaveAs(path); err != nil {
+		return "", fmt.Errorf("保存下载文件失败: %w", err)
+	}
+
+	log.Printf("📥 已下载文件: %s", path)
+	return path, nil
+}
+
 // Screenshot 截取屏幕截图
 func (bm *BrowserManager) Screenshot(filename string) error {
 	if bm.Page == nil {
@@ -218,26 +527,132 @@ func (bm *BrowserManager) Hover(selector string) error {
 
 // SelectOption 从下拉菜单中选择选项
 func (bm *BrowserManager) SelectOption(selector, value string) error {
+	_, err := bm.SelectOptions(selector, SelectByLabel, []string{value})
+	return err
+}
+
+// SelectOptions 按选项文本、值或下标选择一个或多个选项，多个选项用于多选下拉框；返回选中选项的值
+func (bm *BrowserManager) SelectOptions(selector, by string, options []string) ([]string, error) {
 	if bm.Page == nil {
-		return fmt.Errorf("页面未初始化")
+		return nil, fmt.Errorf("页面未初始化")
+	}
+
+	values := playwright.SelectOptionValues{}
+	switch by {
+	case SelectByLabel, "":
+		values.Labels = &options
+	case SelectByValue:
+		values.Values = &options
+	case SelectByIndex:
+		indexes, err := parseOptionIndexes(options)
+		if err != nil {
+			return nil, err
+		}
+		values.Indexes = &indexes
+	default:
+		return nil, fmt.Errorf("无效的选择方式: %s", by)
 	}
 
 	if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
-		return fmt.Errorf("等待选择器元素 %s 失败: %w", selector, err)
+		return nil, fmt.Errorf("等待选择器元素 %s 失败: %w", selector, err)
 	}
 
-	// 使用SelectOptionValues类型
-	_, err := bm.Page.Locator(selector).SelectOption(playwright.SelectOptionValues{
-		Labels: playwright.StringSlice(value),
-	})
+	selected, err := bm.Page.Locator(selector).SelectOption(values)
 	if err != nil {
-		return fmt.Errorf("选择选项 %s 失败: %w", value, err)
+		return nil, fmt.Errorf("选择选项 %v 失败: %w", options, err)
+	}
+
+	log.Printf("📋 已选择选项: %s = %v", selector, selected)
+	return selected, nil
+}
+
+// SetChecked 勾选或取消勾选复选框和单选框，元素已处于目标状态时不做任何操作
+func (bm *BrowserManager) SetChecked(selector string, checked bool) error {
+	if bm.Page == nil {
+		return fmt.Errorf("页面未初始化")
 	}
 
-	log.Printf("📋 已选择选项: %s = %s", selector, value)
+	if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+		return fmt.Errorf("等待勾选元素 %s 失败: %w", selector, err)
+	}
+
+	if err := bm.Page.Locator(selector).SetChecked(checked); err != nil {
+		return fmt.Errorf("设置元素 %s 的勾选状态失败: %w", selector, err)
+	}
+
+	log.Printf("☑️ 已设置勾选状态: %s = %v", selector, checked)
 	return nil
 }
 
+// IsChecked 获取复选框或单选框是否被勾选
+func (bm *BrowserManager) IsChecked(selector string) (bool, error) {
+	if bm.Page == nil {
+		return false, fmt.Errorf("页面未初始化")
+	}
+
+	if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+		return false, fmt.Errorf("等待勾选元素 %s 失败: %w", selector, err)
+	}
+
+	checked, err := bm.Page.Locator(selector).IsChecked()
+	if err != nil {
+		return false, fmt.Errorf("获取元素 %s 的勾选状态失败: %w", selector, err)
+	}
+
+	return checked, nil
+}
+
+// GetValue 获取输入框、文本域或下拉框的当前值，多选下拉框返回所有选中选项的值
+func (bm *BrowserManager) GetValue(selector string) (interface{}, error) {
+	if bm.Page == nil {
+		return nil, fmt.Errorf("页面未初始化")
+	}
+
+	if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+		return nil, fmt.Errorf("等待元素 %s 失败: %w", selector, err)
+	}
+
+	value, err := bm.Page.Locator(selector).Evaluate(`el => {
+		if (el instanceof HTMLSelectElement && el.multiple) {
+			return Array.from(el.selectedOptions, option => option.value);
+		}
+		return el.value === undefined ? null : el.value;
+	}`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取元素 %s 的值失败: %w", selector, err)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("元素 %s 不是输入框、文本域或下拉框，没有值", selector)
+	}
+
+	return value, nil
+}
+
+// Evaluate 在页面中执行脚本并返回结果；selector不为空时脚本函数的第一个参数为匹配的元素，arg为第二个参数，否则arg为第一个参数
+func (bm *BrowserManager) Evaluate(selector, script string, arg interface{}) (interface{}, error) {
+	if bm.Page == nil {
+		return nil, fmt.Errorf("页面未初始化")
+	}
+
+	if selector == "" {
+		result, err := bm.Page.Evaluate(script, arg)
+		if err != nil {
+			return nil, fmt.Errorf("执行脚本失败: %w", err)
+		}
+		return result, nil
+	}
+
+	if err := bm.WaitForSelector(selector, 10*time.Second); err != nil {
+		return nil, fmt.Errorf("等待元素 %s 失败: %w", selector, err)
+	}
+
+	result, err := bm.Page.Locator(selector).Evaluate(script, arg)
+	if err != nil {
+		return nil, fmt.Errorf("在元素 %s 上执行脚本失败: %w", selector, err)
+	}
+	return result, nil
+}
+
 // GetText 获取元素的文本内容
 func (bm *BrowserManager) GetText(selector string) (string, error) {
 	if bm.Page == nil {
@@ -288,6 +703,20 @@ func (bm *BrowserManager) IsVisible(selector string) (bool, error) {
 	return visible, nil
 }
 
+// CountElements 统计匹配选择器的元素数量
+func (bm *BrowserManager) CountElements(selector string) (int, error) {
+	if bm.Page == nil {
+		return 0, fmt.Errorf("页面未初始化")
+	}
+
+	count, err := bm.Page.Locator(selector).Count()
+	if err != nil {
+		return 0, fmt.Errorf("统计元素 %s 数量失败: %w", selector, err)
+	}
+
+	return count, nil
+}
+
 // WaitForElementDisappear 等待元素消失
 func (bm *BrowserManager) WaitForElementDisappear(selector string, timeout time.Duration) error {
 	if bm.Page == nil {
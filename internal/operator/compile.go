@@ -0,0 +1,144 @@
+package operator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// compiledExpressions 已解析的表达式语法树，按表达式源码缓存；语法树求值时不修改自身，可在并行分支间共享
+var compiledExpressions sync.Map // string -> Expression
+
+// CompileExpression 解析表达式并缓存语法树，相同的表达式只解析一次；语法错误不缓存
+func CompileExpression(source string) (Expression, error) {
+	if cached, ok := compiledExpressions.Load(source); ok {
+		return cached.(Expression), nil
+	}
+
+	expression, err := NewParser(source).Parse()
+	if err != nil {
+		return nil, err
+	}
+	compiledExpressions.Store(source, expression)
+	return expression, nil
+}
+
+// compileField 需要预先解析的字段
+type compileField struct {
+	name   string
+	source string
+}
+
+// compileNodeItems 加载时预先解析节点序列中的条件、循环参数和模板并写入缓存，错误给出文件行号和节点路径，如 main.yaml:12 (actions[2].children[0])
+func compileNodeItems(items []NodeItem, path string) error {
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if location := item.Location(); location != "" {
+			itemPath = fmt.Sprintf("%s (%s)", location, itemPath)
+		}
+
+		if item.IsAction() {
+			action := item.Action
+			fields := []compileField{
+				{"selector", action.Selector},
+				{"value", action.Value},
+				{"error_message", action.ErrorMessage},
+				{"url", action.URL},
+				{"key", action.Key},
+				{"save_as", action.SaveAs},
+			}
+			if action.Type != ActionBreak && action.Type != ActionContinue {
+				fields = append(fields, compileField{"target", action.Target})
+			}
+			for j, option := range action.Values {
+				fields = append(fields, compileField{fmt.Sprintf("values[%d]", j), option})
+			}
+			for j, file := range action.Files {
+				fields = append(fields, compileField{fmt.Sprintf("files[%d]", j), file})
+			}
+			if err := compileTemplates(fields); err != nil {
+				return fmt.Errorf("%s: %w", itemPath, err)
+			}
+			if action.Regex != "" {
+				if _, err := compileRegex(action.Regex); err != nil {
+					return fmt.Errorf("%s: %w", itemPath, err)
+				}
+			}
+			continue
+		}
+		if !item.IsControlNode() {
+			continue
+		}
+
+		node := item.ControlNode
+		if err := compileControlNode(node); err != nil {
+			return fmt.Errorf("%s: %w", itemPath, err)
+		}
+
+		childPath := fmt.Sprintf("%s[%d]", path, i)
+		if err := compileNodeItems(node.Children, childPath+".children"); err != nil {
+			return err
+		}
+		if err := compileNodeItems(node.Catch, childPath+".catch"); err != nil {
+			return err
+		}
+		if err := compileNodeItems(node.Finally, childPath+".finally"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileControlNode 解析控制节点自身的表达式和模板
+func compileControlNode(node *ControlNode) error {
+	expressions := []compileField{
+		{"condition", node.Condition},
+		{"expression", node.Expression},
+		{"items", node.Items},
+	}
+	templates := []compileField{
+		{"selector", node.Selector},
+		{"url", node.URL},
+	}
+
+	// for循环参数与resolveLoopBound一致：含 {{ }} 时按模板渲染，非数字字面量按表达式求值
+	for _, bound := range []compileField{{"from", string(node.From)}, {"to", string(node.To)}, {"step", string(node.Step)}} {
+		bound.source = strings.TrimSpace(bound.source)
+		if strings.Contains(bound.source, templateOpen) {
+			templates = append(templates, bound)
+		} else if _, ok := toNumber(bound.source); !ok {
+			expressions = append(expressions, bound)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(node.Args)) {
+		if text, ok := node.Args[name].(string); ok {
+			templates = append(templates, compileField{"args." + name, text})
+		}
+	}
+
+	for _, field := range expressions {
+		if field.source == "" {
+			continue
+		}
+		if _, err := CompileExpression(field.source); err != nil {
+			return fmt.Errorf("%s '%s' 语法错误: %w", field.name, field.source, err)
+		}
+	}
+	return compileTemplates(templates)
+}
+
+// compileTemplates 解析字段中的模板
+func compileTemplates(fields []compileField) error {
+	for _, field := range fields {
+		if !strings.Contains(field.source, templateOpen) {
+			continue
+		}
+		if err := validateTemplate(field.source); err != nil {
+			return fmt.Errorf("%s 的模板语法错误: %w", field.name, err)
+		}
+	}
+	return nil
+}
@@ -3,35 +3,139 @@ package operator
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // ControlType 定义流程控制类型
 const (
-	ControlTypeForLoop     = "for"
-	ControlTypeIfCondition = "if"
-	ControlTypeElseCondition = "else"
+	ControlTypeForLoop         = "for"
+	ControlTypeIfCondition     = "if"
+	ControlTypeElseCondition   = "else"
+	ControlTypeElifCondition   = "elif"
+	ControlTypeElseIfCondition = "else_if" // elif的别名
+	ControlTypeSwitch          = "switch"
+	ControlTypeCase            = "case"
+	ControlTypeDefault         = "default"
+	ControlTypeForeach         = "foreach"
+	ControlTypeWhile           = "while"
+	ControlTypeUntil           = "until"
+	ControlTypeDoUntil         = "do_until" // until的别名
+	ControlTypeTry             = "try"
+	ControlTypeRetry           = "retry"
+	ControlTypeDefine          = "define"
+	ControlTypeCall            = "call"
+	ControlTypeParallel        = "parallel"
+	ControlTypeBranch          = "branch"
 )
 
+// DefaultMaxIterations while/until循环未指定max_iterations时的默认迭代上限
+const DefaultMaxIterations = 100
+
+// controlTypes 所有支持的流程控制类型
+var controlTypes = map[string]bool{
+	ControlTypeForLoop:         true,
+	ControlTypeIfCondition:     true,
+	ControlTypeElseCondition:   true,
+	ControlTypeElifCondition:   true,
+	ControlTypeElseIfCondition: true,
+	ControlTypeSwitch:          true,
+	ControlTypeCase:            true,
+	ControlTypeDefault:         true,
+	ControlTypeForeach:         true,
+	ControlTypeWhile:           true,
+	ControlTypeUntil:           true,
+	ControlTypeDoUntil:         true,
+	ControlTypeTry:             true,
+	ControlTypeRetry:           true,
+	ControlTypeDefine:          true,
+	ControlTypeCall:            true,
+	ControlTypeParallel:        true,
+	ControlTypeBranch:          true,
+}
+
+// IsControlType 检查类型是否为流程控制类型
+func IsControlType(nodeType string) bool {
+	return controlTypes[nodeType]
+}
+
+// LoopBound for循环的起始值、结束值或步长，可以写作数字、{{var}}模板或表达式
+type LoopBound string
 
+// UnmarshalJSON 同时接受JSON数字和字符串，数字按原样保存
+func (b *LoopBound) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = LoopBound(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("for循环参数必须是数字或字符串: %s", data)
+	}
+	*b = LoopBound(number.String())
+	return nil
+}
 
 // ControlNode 流程控制节点基类
 type ControlNode struct {
-	Type     string      `json:"type"`     // 控制类型："for", "if", "else"
-	Children []NodeItem  `json:"children"` // 子节点，可以是Action或ControlNode
-	
+	Type     string     `json:"type" yaml:"type"`         // 控制类型："for", "if", "elif", "else", "switch"等
+	Children []NodeItem `json:"children" yaml:"children"` // 子节点，可以是Action或ControlNode
+
 	// 循环参数
-	Variable  string `json:"variable,omitempty"`  // 循环变量名
-	From      int    `json:"from,omitempty"`       // 起始值
-	To        int    `json:"to,omitempty"`         // 结束值
-	Condition string `json:"condition,omitempty"`  // 条件表达式
-}
+	Variable  string    `json:"variable,omitempty" yaml:"variable,omitempty"`   // 循环变量名
+	From      LoopBound `json:"from,omitempty" yaml:"from,omitempty"`           // 起始值，支持字面量、{{var}}模板或表达式
+	To        LoopBound `json:"to,omitempty" yaml:"to,omitempty"`               // 结束值，支持字面量、{{var}}模板或表达式
+	Step      LoopBound `json:"step,omitempty" yaml:"step,omitempty"`           // 步长，默认为1，负数表示倒序
+	Condition string    `json:"condition,omitempty" yaml:"condition,omitempty"` // 条件表达式
+	Label     string    `json:"label,omitempty" yaml:"label,omitempty"`         // 循环标签，供break/continue的target引用
+
+	// 多路分支参数
+	Expression string      `json:"expression,omitempty" yaml:"expression,omitempty"` // switch求值的表达式
+	Value      interface{} `json:"value,omitempty" yaml:"value,omitempty"`           // case匹配的值
+
+	// 遍历参数
+	Items    string `json:"items,omitempty" yaml:"items,omitempty"`       // foreach遍历的列表或映射表达式
+	Selector string `json:"selector,omitempty" yaml:"selector,omitempty"` // foreach遍历匹配该选择器的所有元素
+	Key      string `json:"key,omitempty" yaml:"key,omitempty"`           // 存储列表下标或映射键的变量名
 
+	// 条件循环参数
+	MaxIterations int `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"` // 最大迭代次数，默认为DefaultMaxIterations
+	Delay         int `json:"delay,omitempty" yaml:"delay,omitempty"`                   // 每次迭代后的等待时间(毫秒)
 
+	// 异常处理参数
+	Catch   []NodeItem `json:"catch,omitempty" yaml:"catch,omitempty"`     // try失败时执行的节点序列
+	Finally []NodeItem `json:"finally,omitempty" yaml:"finally,omitempty"` // 无论成功与否都会执行的节点序列
+
+	// 重试参数，等待时间复用Delay字段
+	Attempts int      `json:"attempts,omitempty" yaml:"attempts,omitempty"` // 最大尝试次数，默认为3
+	Backoff  string   `json:"backoff,omitempty" yaml:"backoff,omitempty"`   // 退避策略："fixed"或"exponential"
+	On       []string `json:"on,omitempty" yaml:"on,omitempty"`             // 仅对这些类型的错误重试
+
+	// 过程定义与调用参数
+	Name      string                 `json:"name,omitempty" yaml:"name,omitempty"`           // define定义的过程名
+	Params    []string               `json:"params,omitempty" yaml:"params,omitempty"`       // define声明的参数名
+	Defaults  map[string]interface{} `json:"defaults,omitempty" yaml:"defaults,omitempty"`   // define声明的参数默认值
+	Returns   []string               `json:"returns,omitempty" yaml:"returns,omitempty"`     // define声明的返回变量名
+	Procedure string                 `json:"procedure,omitempty" yaml:"procedure,omitempty"` // call调用的过程名
+	Args      map[string]interface{} `json:"args,omitempty" yaml:"args,omitempty"`           // call传入的参数，字符串支持{{var}}模板
+	Output    map[string]string      `json:"output,omitempty" yaml:"output,omitempty"`       // call将返回变量映射为调用方变量名：调用方变量名 -> 返回变量名
+
+	// 并行参数，branch的名称复用Name字段
+	Isolation string `json:"isolation,omitempty" yaml:"isolation,omitempty"` // parallel分支的隔离方式："page"或"context"，默认为page
+	URL       string `json:"url,omitempty" yaml:"url,omitempty"`             // branch开始前导航到的地址，默认为当前页面地址
+}
 
 // NodeItem 定义节点项，可以是Action或ControlNode
 type NodeItem struct {
-	Action      *Action      `json:"action,omitempty" yaml:"action,omitempty"`       // 基本操作
+	Action      *Action      `json:"action,omitempty" yaml:"action,omitempty"`             // 基本操作
 	ControlNode *ControlNode `json:"control_node,omitempty" yaml:"control_node,omitempty"` // 控制节点
+	Include     string       `json:"include,omitempty" yaml:"include,omitempty"`           // 引入的任务片段文件，加载时展开
+
+	Source string `json:"-" yaml:"-"` // 节点所在的文件
+	Line   int    `json:"-" yaml:"-"` // 节点在文件中的行号
 }
 
 // ExecutionContext 执行上下文，用于存储变量和控制流状态
@@ -39,6 +143,10 @@ type ExecutionContext struct {
 	Variables    map[string]interface{} `json:"variables"`     // 变量表
 	ControlFlow  *ControlFlow           `json:"control_flow"`  // 控制流状态
 	OutputValues map[string]string      `json:"output_values"` // 输出值存储
+	Attempts     []AttemptRecord        `json:"attempts"`      // 重试策略下每次尝试的记录
+	Strict       bool                   `json:"strict"`        // 严格模式：表达式中的类型不匹配视为错误
+
+	scopes []map[string]interface{} // 嵌套作用域栈，栈顶为最内层作用域
 }
 
 // ControlFlow 控制流状态
@@ -46,6 +154,7 @@ type ControlFlow struct {
 	BreakSignal    bool   `json:"break_signal"`    // break信号
 	ContinueSignal bool   `json:"continue_signal"` // continue信号
 	CurrentLoop    string `json:"current_loop"`    // 当前循环标识
+	TargetLoop     string `json:"target_loop"`     // break/continue信号的目标循环标识，为空表示最内层循环
 }
 
 // IsValid 验证控制节点是否有效
@@ -57,6 +166,31 @@ func (cn *ControlNode) IsValid() error {
 		return cn.validateIfCondition()
 	case ControlTypeElseCondition:
 		return cn.validateElseCondition()
+	case ControlTypeElifCondition, ControlTypeElseIfCondition:
+		return cn.validateElifCondition()
+	case ControlTypeSwitch:
+		return cn.validateSwitch()
+	case ControlTypeCase:
+		return cn.validateCase()
+	case ControlTypeDefault:
+		return cn.validateDefault()
+	case ControlTypeForeach:
+		return cn.validateForeach()
+	case ControlTypeWhile, ControlTypeUntil, ControlTypeDoUntil:
+		return cn.validateConditionLoop()
+	case ControlTypeTry:
+		return cn.validateTry()
+	case ControlTypeRetry:
+		return cn.validateRetry()
+	case ControlTypeDefine:
+		procedure := procedureFromNode(cn)
+		return procedure.Validate()
+	case ControlTypeCall:
+		return cn.validateCall()
+	case ControlTypeParallel:
+		return cn.validateParallel()
+	case ControlTypeBranch:
+		return cn.validateBranch()
 	default:
 		return fmt.Errorf("不支持的流程控制类型: %s", cn.Type)
 	}
@@ -64,29 +198,342 @@ func (cn *ControlNode) IsValid() error {
 
 // validateForLoop 验证for循环配置
 func (cn *ControlNode) validateForLoop() error {
-	// 这里可以添加具体的验证逻辑
+	if strings.TrimSpace(string(cn.To)) == "" {
+		return fmt.Errorf("for循环必须提供to结束值")
+	}
+	// 步长为字面量时可以在加载阶段检查
+	if step, err := strconv.ParseFloat(strings.TrimSpace(string(cn.Step)), 64); err == nil && step == 0 {
+		return fmt.Errorf("for循环的step不能为0")
+	}
 	return nil
 }
 
 // validateIfCondition 验证条件分支配置
 func (cn *ControlNode) validateIfCondition() error {
-	// 这里可以添加具体的验证逻辑
+	if strings.TrimSpace(cn.Condition) == "" {
+		return fmt.Errorf("if分支必须提供condition条件表达式")
+	}
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("if分支必须包含至少一个子节点")
+	}
 	return nil
 }
 
-
-
 // validateElseCondition 验证else分支配置
 func (cn *ControlNode) validateElseCondition() error {
 	// 验证else节点必须有子节点
 	if len(cn.Children) == 0 {
 		return fmt.Errorf("else分支必须包含至少一个子节点")
 	}
-	// 在实际执行时，else节点需要检查前一个节点是否为if节点
-	// 这个验证在解码时无法完成，需要在执行时进行
+	// else节点是否紧跟在if节点之后由ValidateNodeItems在加载时检查
+	return nil
+}
+
+// validateElifCondition 验证elif分支配置
+func (cn *ControlNode) validateElifCondition() error {
+	if strings.TrimSpace(cn.Condition) == "" {
+		return fmt.Errorf("%s分支必须提供condition条件表达式", cn.Type)
+	}
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("%s分支必须包含至少一个子节点", cn.Type)
+	}
 	return nil
 }
 
+// validateSwitch 验证switch多路分支配置
+func (cn *ControlNode) validateSwitch() error {
+	if strings.TrimSpace(cn.Expression) == "" {
+		return fmt.Errorf("switch必须提供expression表达式")
+	}
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("switch必须包含至少一个case分支")
+	}
+
+	for i, child := range cn.Children {
+		if !child.IsControlNode() || (child.ControlNode.Type != ControlTypeCase && child.ControlNode.Type != ControlTypeDefault) {
+			return fmt.Errorf("switch的第%d个子节点必须是case或default分支", i+1)
+		}
+		// default必须是最后一个分支，避免其后的case永远无法匹配
+		if child.ControlNode.Type == ControlTypeDefault && i != len(cn.Children)-1 {
+			return fmt.Errorf("default分支必须是switch的最后一个子节点")
+		}
+	}
+	return nil
+}
+
+// validateCase 验证case分支配置
+func (cn *ControlNode) validateCase() error {
+	if cn.Value == nil {
+		return fmt.Errorf("case分支必须提供value匹配值")
+	}
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("case分支必须包含至少一个子节点")
+	}
+	return nil
+}
+
+// validateDefault 验证default分支配置
+func (cn *ControlNode) validateDefault() error {
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("default分支必须包含至少一个子节点")
+	}
+	return nil
+}
+
+// validateForeach 验证foreach遍历配置
+func (cn *ControlNode) validateForeach() error {
+	hasItems := strings.TrimSpace(cn.Items) != ""
+	hasSelector := strings.TrimSpace(cn.Selector) != ""
+	if hasItems == hasSelector {
+		return fmt.Errorf("foreach必须且只能提供items或selector其中之一")
+	}
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("foreach必须包含至少一个子节点")
+	}
+	return nil
+}
+
+// validateConditionLoop 验证while/until条件循环配置
+func (cn *ControlNode) validateConditionLoop() error {
+	if strings.TrimSpace(cn.Condition) == "" {
+		return fmt.Errorf("%s循环必须提供condition条件表达式", cn.Type)
+	}
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("%s循环必须包含至少一个子节点", cn.Type)
+	}
+	if cn.MaxIterations < 0 {
+		return fmt.Errorf("%s循环的max_iterations不能为负数", cn.Type)
+	}
+	if cn.Delay < 0 {
+		return fmt.Errorf("%s循环的delay不能为负数", cn.Type)
+	}
+	return nil
+}
+
+// validateTry 验证try异常处理配置
+func (cn *ControlNode) validateTry() error {
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("try必须包含至少一个子节点")
+	}
+	if len(cn.Catch) == 0 && len(cn.Finally) == 0 {
+		return fmt.Errorf("try必须提供catch或finally其中至少一个")
+	}
+	return nil
+}
+
+// validateRetry 验证retry重试块配置
+func (cn *ControlNode) validateRetry() error {
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("retry必须包含至少一个子节点")
+	}
+	policy := cn.RetryPolicy()
+	return policy.Validate()
+}
+
+// RetryPolicy 获取retry节点的重试策略
+func (cn *ControlNode) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts: cn.Attempts,
+		Delay:    cn.Delay,
+		Backoff:  cn.Backoff,
+		On:       cn.On,
+	}
+}
+
+// validateCall 验证call调用配置，过程是否存在及参数是否匹配在加载任务时检查
+func (cn *ControlNode) validateCall() error {
+	if strings.TrimSpace(cn.Procedure) == "" {
+		return fmt.Errorf("call必须提供procedure过程名")
+	}
+	return nil
+}
+
+// validateParallel 验证parallel并行配置
+func (cn *ControlNode) validateParallel() error {
+	switch cn.Isolation {
+	case "", IsolationPage, IsolationContext:
+	default:
+		return fmt.Errorf("不支持的并行隔离方式: %s", cn.Isolation)
+	}
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("parallel必须包含至少一个branch分支")
+	}
+
+	names := make(map[string]bool, len(cn.Children))
+	for i, child := range cn.Children {
+		if !child.IsControlNode() || child.ControlNode.Type != ControlTypeBranch {
+			return fmt.Errorf("parallel的第%d个子节点必须是branch分支", i+1)
+		}
+		if name := child.ControlNode.Name; name != "" {
+			if names[name] {
+				return fmt.Errorf("parallel的分支名称 %s 重复", name)
+			}
+			names[name] = true
+		}
+	}
+	return nil
+}
+
+// validateBranch 验证branch并行分支配置
+func (cn *ControlNode) validateBranch() error {
+	if len(cn.Children) == 0 {
+		return fmt.Errorf("branch分支必须包含至少一个子节点")
+	}
+	return nil
+}
+
+// isBranchType 检查是否为if/elif分支，用于判断else和elif能否接在其后
+func isBranchType(nodeType string) bool {
+	return nodeType == ControlTypeIfCondition || nodeType == ControlTypeElifCondition || nodeType == ControlTypeElseIfCondition
+}
+
+// isLoopType 检查是否为循环类型
+func isLoopType(nodeType string) bool {
+	switch nodeType {
+	case ControlTypeForLoop, ControlTypeForeach, ControlTypeWhile, ControlTypeUntil, ControlTypeDoUntil:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateNodeItems 递归验证节点序列，包括控制节点配置、分支的配对关系和循环标签的引用
+func ValidateNodeItems(items []NodeItem) error {
+	return validateNodeItems(items, "", loopScope{})
+}
+
+// loopScope 节点所在的循环环境，用于验证break/continue；并行分支在独立的执行器中运行，外层循环对分支不可见
+type loopScope struct {
+	labels      []string // 外层循环的标签
+	inLoop      bool     // 是否位于循环中
+	outerBranch bool     // 是否位于并行分支中，且分支之外有循环
+}
+
+// validateNodeItems 验证节点序列，parentType为所在的父控制节点类型，loops为外层循环的环境
+func validateNodeItems(items []NodeItem, parentType string, loops loopScope) error {
+	afterBranch := false
+	for i, item := range items {
+		if item.IsAction() {
+			afterBranch = false
+			if err := validateLoopSignal(item.Action, loops); err != nil {
+				return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+			}
+			if err := validateNavigation(item.Action); err != nil {
+				return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+			}
+			if err := validateKeyboard(item.Action); err != nil {
+				return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+			}
+			if err := validateForm(item.Action); err != nil {
+				return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+			}
+			if err := validateTransfer(item.Action); err != nil {
+				return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+			}
+			if err := validateEvaluate(item.Action); err != nil {
+				return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+			}
+			if item.Action.Retry != nil {
+				if err := item.Action.Retry.Validate(); err != nil {
+					return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+				}
+			}
+			continue
+		}
+		if !item.IsControlNode() {
+			afterBranch = false
+			continue
+		}
+
+		node := item.ControlNode
+		if err := node.IsValid(); err != nil {
+			return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+		}
+
+		switch node.Type {
+		case ControlTypeElseCondition, ControlTypeElifCondition, ControlTypeElseIfCondition:
+			// else和elif必须紧跟在同级的if或elif节点之后
+			if !afterBranch {
+				return fmt.Errorf("第%d个节点%s: %s分支前缺少对应的if节点", i+1, locationSuffix(item), node.Type)
+			}
+		case ControlTypeCase, ControlTypeDefault:
+			if parentType != ControlTypeSwitch {
+				return fmt.Errorf("第%d个节点%s: %s分支只能出现在switch中", i+1, locationSuffix(item), node.Type)
+			}
+		case ControlTypeBranch:
+			if parentType != ControlTypeParallel {
+				return fmt.Errorf("第%d个节点%s: branch分支只能出现在parallel中", i+1, locationSuffix(item))
+			}
+		case ControlTypeDefine:
+			// define的过程体作为独立的序列验证，不继承外层的循环标签；过程体中的define同样不会被注册
+			if parentType != "" {
+				return fmt.Errorf("第%d个节点%s: define只能出现在任务操作序列的顶层，不能嵌套在控制节点或过程体中", i+1, locationSuffix(item))
+			}
+			afterBranch = false
+			continue
+		}
+		afterBranch = isBranchType(node.Type)
+
+		childLoops := loops
+		if node.Label != "" {
+			if !isLoopType(node.Type) {
+				return fmt.Errorf("第%d个节点%s: 只有循环节点可以设置label，%s节点不支持", i+1, locationSuffix(item), node.Type)
+			}
+			for _, label := range loops.labels {
+				if label == node.Label {
+					return fmt.Errorf("第%d个节点%s: 循环标签 %s 与外层循环重复", i+1, locationSuffix(item), node.Label)
+				}
+			}
+			childLoops.labels = append(append([]string{}, loops.labels...), node.Label)
+		}
+		if isLoopType(node.Type) {
+			childLoops.inLoop = true
+		}
+		// 并行分支在独立的执行器中运行，不能break/continue外层循环
+		if node.Type == ControlTypeParallel {
+			childLoops = loopScope{outerBranch: loops.inLoop || loops.outerBranch}
+		}
+
+		if err := validateNodeItems(node.Children, node.Type, childLoops); err != nil {
+			return fmt.Errorf("第%d个%s节点%s的子节点: %w", i+1, node.Type, locationSuffix(item), err)
+		}
+		if err := validateNodeItems(node.Catch, node.Type, childLoops); err != nil {
+			return fmt.Errorf("第%d个%s节点%s的catch: %w", i+1, node.Type, locationSuffix(item), err)
+		}
+		if err := validateNodeItems(node.Finally, node.Type, childLoops); err != nil {
+			return fmt.Errorf("第%d个%s节点%s的finally: %w", i+1, node.Type, locationSuffix(item), err)
+		}
+	}
+	return nil
+}
+
+// locationSuffix 生成错误信息中的节点位置后缀
+func locationSuffix(item NodeItem) string {
+	if location := item.Location(); location != "" {
+		return fmt.Sprintf("(%s)", location)
+	}
+	return ""
+}
+
+// validateLoopSignal 验证break/continue引用的循环标签是否存在于外层循环中，并行分支中的break/continue不能作用于分支之外的循环
+func validateLoopSignal(action *Action, loops loopScope) error {
+	if action.Type != ActionBreak && action.Type != ActionContinue {
+		return nil
+	}
+	if action.Target == "" {
+		if !loops.inLoop && loops.outerBranch {
+			return fmt.Errorf("%s不能作用于并行分支之外的循环，分支中没有可退出的循环", action.Type)
+		}
+		return nil
+	}
+	for _, label := range loops.labels {
+		if label == action.Target {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s引用了未知的循环标签: %s", action.Type, action.Target)
+}
+
 // NewExecutionContext 创建新的执行上下文
 func NewExecutionContext() *ExecutionContext {
 	return &ExecutionContext{
@@ -100,17 +547,49 @@ func NewExecutionContext() *ExecutionContext {
 	}
 }
 
-// SetVariable 设置变量值
+// SetVariable 设置变量值，若某层作用域已定义该变量则更新最内层的定义，否则写入全局变量表
 func (ec *ExecutionContext) SetVariable(name string, value interface{}) {
+	for i := len(ec.scopes) - 1; i >= 0; i-- {
+		if _, exists := ec.scopes[i][name]; exists {
+			ec.scopes[i][name] = value
+			return
+		}
+	}
 	ec.Variables[name] = value
 }
 
-// GetVariable 获取变量值
+// GetVariable 获取变量值，从最内层作用域向外查找
 func (ec *ExecutionContext) GetVariable(name string) interface{} {
-	if val, exists := ec.Variables[name]; exists {
-		return val
+	val, _ := ec.LookupVariable(name)
+	return val
+}
+
+// LookupVariable 从最内层作用域向外查找变量，最后查找全局变量表
+func (ec *ExecutionContext) LookupVariable(name string) (interface{}, bool) {
+	for i := len(ec.scopes) - 1; i >= 0; i-- {
+		if val, exists := ec.scopes[i][name]; exists {
+			return val, true
+		}
+	}
+	val, exists := ec.Variables[name]
+	return val, exists
+}
+
+// RecordAttempt 记录一次尝试
+func (ec *ExecutionContext) RecordAttempt(record AttemptRecord) {
+	ec.Attempts = append(ec.Attempts, record)
+}
+
+// PushScope 推入新的作用域
+func (ec *ExecutionContext) PushScope(scope map[string]interface{}) {
+	ec.scopes = append(ec.scopes, scope)
+}
+
+// PopScope 弹出最内层作用域
+func (ec *ExecutionContext) PopScope() {
+	if len(ec.scopes) > 0 {
+		ec.scopes = ec.scopes[:len(ec.scopes)-1]
 	}
-	return nil
 }
 
 // SignalBreak 发送break信号
@@ -127,6 +606,7 @@ func (ec *ExecutionContext) SignalContinue() {
 func (ec *ExecutionContext) ResetControlFlow() {
 	ec.ControlFlow.BreakSignal = false
 	ec.ControlFlow.ContinueSignal = false
+	ec.ControlFlow.TargetLoop = ""
 }
 
 // MarshalJSON NodeItem的自定义JSON序列化
@@ -137,19 +617,30 @@ func (ni *NodeItem) MarshalJSON() ([]byte, error) {
 	if ni.ControlNode != nil {
 		return json.Marshal(ni.ControlNode)
 	}
+	if ni.Include != "" {
+		return json.Marshal(map[string]string{"include": ni.Include})
+	}
 	return []byte("null"), nil
 }
 
 // UnmarshalJSON NodeItem的自定义JSON反序列化
 func (ni *NodeItem) UnmarshalJSON(data []byte) error {
-	// 首先尝试解析为ControlNode（因为某些类型如"if", "else"需要优先作为控制节点处理）
-	var controlNode ControlNode
-	if err := json.Unmarshal(data, &controlNode); err == nil && controlNode.Type != "" {
-		// 检查是否为支持的控制节点类型
-		if controlNode.Type == ControlTypeForLoop || controlNode.Type == ControlTypeIfCondition || controlNode.Type == ControlTypeElseCondition {
-			ni.ControlNode = &controlNode
-			return nil
+	// 首先按type判断是否为ControlNode（因为某些类型如"if", "else"需要优先作为控制节点处理）
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("无法解析节点项: %w", err)
+	}
+
+	// 控制节点的解析错误直接返回，避免被当作同名的Action
+	if IsControlType(header.Type) {
+		var controlNode ControlNode
+		if err := json.Unmarshal(data, &controlNode); err != nil {
+			return fmt.Errorf("无法解析%s节点: %w", header.Type, err)
 		}
+		ni.ControlNode = &controlNode
+		return nil
 	}
 
 	// 尝试解析为Action
@@ -163,25 +654,52 @@ func (ni *NodeItem) UnmarshalJSON(data []byte) error {
 }
 
 // UnmarshalYAML NodeItem的自定义YAML反序列化
-func (ni *NodeItem) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	// 首先尝试解析为ControlNode
-	var controlNode ControlNode
-	if err := unmarshal(&controlNode); err == nil && controlNode.Type != "" {
-		// 检查是否为支持的控制节点类型
-		if controlNode.Type == ControlTypeForLoop || controlNode.Type == ControlTypeIfCondition || controlNode.Type == ControlTypeElseCondition {
-			ni.ControlNode = &controlNode
-			return nil
+func (ni *NodeItem) UnmarshalYAML(value *yaml.Node) error {
+	ni.Line = value.Line
+
+	var header struct {
+		Type    string `yaml:"type"`
+		Include string `yaml:"include"`
+	}
+	if err := value.Decode(&header); err != nil {
+		return fmt.Errorf("第%d行: 无法解析节点项: %w", value.Line, err)
+	}
+
+	// include节点在加载时展开为被引入文件的操作序列
+	if header.Include != "" {
+		ni.Include = header.Include
+		return nil
+	}
+
+	// 控制节点的解析错误直接返回，避免被当作Action吞掉子节点中的错误
+	if IsControlType(header.Type) {
+		var controlNode ControlNode
+		if err := value.Decode(&controlNode); err != nil {
+			return err
 		}
+		ni.ControlNode = &controlNode
+		return nil
 	}
 
 	// 尝试解析为Action
 	var action Action
-	if err := unmarshal(&action); err == nil && action.Type != "" {
+	if err := value.Decode(&action); err == nil && action.Type != "" {
 		ni.Action = &action
 		return nil
 	}
 
-	return fmt.Errorf("无法解析节点项，既不是有效的Action也不是ControlNode")
+	return fmt.Errorf("第%d行: 无法解析节点项，既不是有效的Action也不是ControlNode", value.Line)
+}
+
+// Location 返回节点在任务文件中的位置，用于错误提示
+func (ni *NodeItem) Location() string {
+	if ni.Line == 0 {
+		return ""
+	}
+	if ni.Source == "" {
+		return fmt.Sprintf("第%d行", ni.Line)
+	}
+	return fmt.Sprintf("%s:%d", ni.Source, ni.Line)
 }
 
 // IsAction 检查是否为Action节点
@@ -192,4 +710,4 @@ func (ni *NodeItem) IsAction() bool {
 // IsControlNode 检查是否为ControlNode节点
 func (ni *NodeItem) IsControlNode() bool {
 	return ni.ControlNode != nil
-}
\ No newline at end of file
+}
@@ -1,19 +1,33 @@
 package operator
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// actionInterval 相邻节点之间的等待时间，提高执行稳定性
+var actionInterval = 500 * time.Millisecond
+
 // ControlExecutor 流程控制执行器
 type ControlExecutor struct {
 	TaskManager    *TaskManager
 	Context        *ExecutionContext
 	LoopStack      []string                  // 循环栈，用于嵌套循环管理
 	ScopeVariables map[string]map[string]any // 嵌套作用域变量存储
+
+	Procedures map[string]*Procedure // 可调用的过程表
+
+	loopCounter int               // 用于生成唯一的循环ID
+	loopLabels  map[string]string // 循环ID到循环标签的映射
+	callStack   []string          // 当前的过程调用链
+	ctx         context.Context   // 并行分支的取消信号，其他分支失败时被取消
 }
 
 // NewControlExecutor 创建新的控制执行器
@@ -23,29 +37,63 @@ func NewControlExecutor(tm *TaskManager) *ControlExecutor {
 		Context:        NewExecutionContext(),
 		LoopStack:      make([]string, 0),
 		ScopeVariables: make(map[string]map[string]any),
+		Procedures:     make(map[string]*Procedure),
+		loopLabels:     make(map[string]string),
+		ctx:            context.Background(),
 	}
 }
 
 // ExecuteNodeItems 执行节点项序列
 func (ce *ControlExecutor) ExecuteNodeItems(items []NodeItem) error {
+	// 记录同级if/elif分支链的状态，elif和else仅在链中此前的条件都为假时执行
+	inBranch := false
+	branchMatched := false
+
 	for _, item := range items {
-		// 检查控制流信号
-		if ce.Context.ControlFlow.BreakSignal {
-			ce.Context.ResetControlFlow()
+		// 所在的并行分支已被取消时停止执行
+		if err := ce.ctx.Err(); err != nil {
+			return errBranchCancelled
+		}
+
+		// 检查控制流信号，信号由所在的循环负责处理
+		if ce.Context.ControlFlow.BreakSignal || ce.Context.ControlFlow.ContinueSignal {
 			break
 		}
 
-		if ce.Context.ControlFlow.ContinueSignal {
-			ce.Context.ResetControlFlow()
-			continue
+		var err error
+		switch {
+		case item.IsControlNode() && item.ControlNode.Type == ControlTypeIfCondition:
+			branchMatched, err = ce.executeIfCondition(item.ControlNode)
+			inBranch = true
+
+		case item.IsControlNode() && (item.ControlNode.Type == ControlTypeElifCondition || item.ControlNode.Type == ControlTypeElseIfCondition):
+			if !inBranch {
+				err = fmt.Errorf("%s分支前缺少对应的if节点", item.ControlNode.Type)
+			} else if branchMatched {
+				log.Printf("❌ 前序条件已成立，跳过%s分支", item.ControlNode.Type)
+			} else {
+				branchMatched, err = ce.executeIfCondition(item.ControlNode)
+			}
+
+		case item.IsControlNode() && item.ControlNode.Type == ControlTypeElseCondition:
+			if !inBranch {
+				err = fmt.Errorf("else分支前缺少对应的if节点")
+			} else {
+				err = ce.executeElseCondition(item.ControlNode, branchMatched)
+			}
+			inBranch = false
+
+		default:
+			err = ce.ExecuteNodeItem(item)
+			inBranch = false
 		}
 
-		if err := ce.ExecuteNodeItem(item); err != nil {
+		if err != nil {
 			return err
 		}
 
 		// 操作间添加短暂延迟，提高执行稳定性
-		time.Sleep(500 * time.Millisecond)
+		time.Sleep(actionInterval)
 	}
 	return nil
 }
@@ -53,6 +101,12 @@ func (ce *ControlExecutor) ExecuteNodeItems(items []NodeItem) error {
 // ExecuteNodeItem 执行单个节点项
 func (ce *ControlExecutor) ExecuteNodeItem(item NodeItem) error {
 	if item.IsAction() {
+		if item.Action.Retry != nil {
+			target := strings.TrimSpace(fmt.Sprintf("%s %s", item.Action.Type, item.Action.Selector))
+			return ce.executeWithRetry(target, *item.Action.Retry, func() error {
+				return ce.executeAction(item.Action)
+			})
+		}
 		return ce.executeAction(item.Action)
 	}
 
@@ -63,52 +117,51 @@ func (ce *ControlExecutor) ExecuteNodeItem(item NodeItem) error {
 	return fmt.Errorf("无效的节点项，既不是Action也不是ControlNode")
 }
 
-// replaceVariables 替换字符串中的模板变量
-func (ce *ControlExecutor) replaceVariables(input string) string {
-	result := input
-	
-	// 替换 {{variable}} 格式的变量
-	for key, value := range ce.Context.Variables {
-		placeholder := "{{" + key + "}}"
-		var stringValue string
-		
-		switch v := value.(type) {
-		case string:
-			stringValue = v
-		case int:
-			stringValue = strconv.Itoa(v)
-		case float64:
-			stringValue = strconv.FormatFloat(v, 'f', -1, 64)
-		case bool:
-			stringValue = strconv.FormatBool(v)
-		default:
-			stringValue = fmt.Sprintf("%v", v)
-		}
-		
-		result = strings.ReplaceAll(result, placeholder, stringValue)
+// replaceVariables 渲染字符串中的 {{ }} 模板，变量从最内层作用域向外查找
+func (ce *ControlExecutor) replaceVariables(input string) (string, error) {
+	return RenderTemplate(input, ce.Context)
+}
+
+// formatVariable 将变量值格式化为模板中使用的字符串
+func formatVariable(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(DefaultDateLayout)
+	default:
+		return fmt.Sprintf("%v", v)
 	}
-	
-	return result
 }
 
 // executeAction 执行单个动作
 func (ce *ControlExecutor) executeAction(action *Action) error {
 	// 检查是否为控制流操作
-	if action.Type == "break" {
-		ce.HandleBreak()
-		return nil
+	if action.Type == ActionBreak {
+		return ce.HandleBreak(action.Target)
 	}
 
-	if action.Type == "continue" {
-		ce.HandleContinue()
-		return nil
+	if action.Type == ActionContinue {
+		return ce.HandleContinue(action.Target)
 	}
 
 	// 替换模板变量
-	selector := ce.replaceVariables(action.Selector)
-	target := ce.replaceVariables(action.Target)
-	value := ce.replaceVariables(action.Value)
-	errorMessage := ce.replaceVariables(action.ErrorMessage)
+	selector, target, value, errorMessage, url, key, saveAs := action.Selector, action.Target, action.Value, action.ErrorMessage, action.URL, action.Key, action.SaveAs
+	for _, field := range []*string{&selector, &target, &value, &errorMessage, &url, &key, &saveAs} {
+		rendered, err := ce.replaceVariables(*field)
+		if err != nil {
+			return fmt.Errorf("%s操作的模板替换失败: %w", action.Type, err)
+		}
+		*field = rendered
+	}
 
 	// 执行常规动作
 	var err error
@@ -127,11 +180,10 @@ func (ce *ControlExecutor) executeAction(action *Action) error {
 		err = ce.TaskManager.BrowserManager.Hover(selector)
 
 	case ActionSelect:
-		if value == "" {
-			err = fmt.Errorf("select操作需要提供value参数")
-		} else {
-			err = ce.TaskManager.BrowserManager.SelectOption(selector, value)
-		}
+		err = ce.selectOptions(action, selector, value)
+
+	case ActionCheck, ActionUncheck:
+		err = ce.TaskManager.BrowserManager.SetChecked(selector, action.Type == ActionCheck)
 
 	case ActionScroll:
 		err = ce.TaskManager.BrowserManager.ScrollToElement(selector)
@@ -166,8 +218,12 @@ func (ce *ControlExecutor) executeAction(action *Action) error {
 			err = getTextErr
 		} else {
 			log.Printf("📝 获取元素文本: %s = '%s'", selector, text)
-			if action.OutputKey != "" {
-				ce.Context.SetVariable(action.OutputKey, text)
+			var result interface{} = text
+			if action.Regex != "" {
+				result, err = extractText(text, action.Regex)
+			}
+			if err == nil && action.OutputKey != "" {
+				ce.Context.SetVariable(action.OutputKey, result)
 				log.Printf("📋 文本已存储到变量: %s", action.OutputKey)
 			}
 		}
@@ -188,15 +244,60 @@ func (ce *ControlExecutor) executeAction(action *Action) error {
 			}
 		}
 
+	case ActionGetValue:
+		fieldValue, getValueErr := ce.TaskManager.BrowserManager.GetValue(selector)
+		if getValueErr != nil {
+			err = getValueErr
+		} else {
+			log.Printf("📝 获取元素的值: %s = %v", selector, fieldValue)
+			if action.OutputKey != "" {
+				ce.Context.SetVariable(action.OutputKey, fieldValue)
+				log.Printf("📋 值已存储到变量: %s", action.OutputKey)
+			}
+		}
+
+	case ActionIsChecked:
+		checked, isCheckedErr := ce.TaskManager.BrowserManager.IsChecked(selector)
+		if isCheckedErr != nil {
+			err = isCheckedErr
+		} else {
+			log.Printf("☑️ 获取勾选状态: %s = %v", selector, checked)
+			if action.OutputKey != "" {
+				ce.Context.SetVariable(action.OutputKey, checked)
+				log.Printf("📋 勾选状态已存储到变量: %s", action.OutputKey)
+			}
+		}
+
+	case ActionTypeText, ActionPress, ActionKeyDown, ActionKeyUp:
+		err = ce.pressKeys(action.Type, selector, value, key, time.Duration(action.Delay)*time.Millisecond)
+
+	case ActionGoto, ActionReload, ActionGoBack, ActionGoForward:
+		err = ce.navigate(action.Type, url, action.WaitUntil, time.Duration(action.Timeout)*time.Second)
+
+	case ActionUpload:
+		err = ce.uploadFiles(action, selector)
+
+	case ActionDownload:
+		err = ce.downloadFile(action, selector, saveAs)
+
+	case ActionEvaluate:
+		err = ce.evaluateScript(action, selector)
+
 	default:
 		err = fmt.Errorf("不支持的操作类型: %s", action.Type)
 	}
 
 	if err != nil {
+		// 保留原始错误及其类型，供重试策略和catch判断
+		actionErr := &ActionError{
+			Message: fmt.Sprintf("操作失败: %s - %v", action.Type, err),
+			Kind:    ClassifyError(err),
+			Err:     err,
+		}
 		if errorMessage != "" {
-			return fmt.Errorf("操作失败: %s", errorMessage)
+			actionErr.Message = fmt.Sprintf("操作失败: %s", errorMessage)
 		}
-		return fmt.Errorf("操作失败: %s - %v", action.Type, err)
+		return actionErr
 	}
 
 	return nil
@@ -213,9 +314,34 @@ func (ce *ControlExecutor) executeControlNode(node *ControlNode) error {
 	case ControlTypeForLoop:
 		return ce.executeForLoop(node)
 	case ControlTypeIfCondition:
-		return ce.executeIfCondition(node)
-	case ControlTypeElseCondition:
-		return ce.executeElseCondition(node)
+		_, err := ce.executeIfCondition(node)
+		return err
+	case ControlTypeElseCondition, ControlTypeElifCondition, ControlTypeElseIfCondition:
+		// else和elif必须由ExecuteNodeItems根据前序分支的结果来执行
+		return fmt.Errorf("%s分支前缺少对应的if节点", node.Type)
+	case ControlTypeSwitch:
+		return ce.executeSwitch(node)
+	case ControlTypeForeach:
+		return ce.executeForeach(node)
+	case ControlTypeWhile, ControlTypeUntil, ControlTypeDoUntil:
+		return ce.executeConditionLoop(node)
+	case ControlTypeTry:
+		return ce.executeTry(node)
+	case ControlTypeDefine:
+		// 过程在加载任务时注册，执行到define节点时无需操作
+		return nil
+	case ControlTypeCall:
+		return ce.executeCall(node)
+	case ControlTypeRetry:
+		return ce.executeWithRetry("retry块", node.RetryPolicy(), func() error {
+			return ce.ExecuteNodeItems(node.Children)
+		})
+	case ControlTypeParallel:
+		return ce.executeParallel(node)
+	case ControlTypeCase, ControlTypeDefault:
+		return fmt.Errorf("%s分支只能出现在switch中", node.Type)
+	case ControlTypeBranch:
+		return fmt.Errorf("branch分支只能出现在parallel中")
 	default:
 		return fmt.Errorf("不支持的控制节点类型: %s", node.Type)
 	}
@@ -226,145 +352,405 @@ func (ce *ControlExecutor) executeForLoop(node *ControlNode) error {
 	log.Printf("🔄 开始执行for循环")
 
 	// 解析循环参数
-	var loopVar string
-	var start, end int
-
-	// 从node中解析参数
-	loopVar = node.Variable
+	loopVar := node.Variable
 	if loopVar == "" {
 		loopVar = "i"
 	}
-	
-	start = node.From
-	end = node.To
 
-	log.Printf("🔄 循环参数: 变量=%s, 起始=%d, 结束=%d", loopVar, start, end)
+	// 循环边界在进入循环时求值一次
+	start, err := ce.resolveLoopBound("from", string(node.From), 0)
+	if err != nil {
+		return err
+	}
+	end, err := ce.resolveLoopBound("to", string(node.To), 0)
+	if err != nil {
+		return err
+	}
+	step, err := ce.resolveLoopBound("step", string(node.Step), 1)
+	if err != nil {
+		return err
+	}
+	if step == 0 {
+		return fmt.Errorf("for循环的step不能为0")
+	}
 
-	// 使用真正的单层循环结构，避免嵌套
-	currentIndex := start
-	
-	for currentIndex <= end {
-		// 检查控制流信号
-		if ce.Context.ControlFlow.BreakSignal {
-			ce.Context.ResetControlFlow()
-			break
-		}
+	log.Printf("🔄 循环参数: 变量=%s, 起始=%d, 结束=%d, 步长=%d", loopVar, start, end, step)
+
+	// 循环体使用独立的作用域，循环变量不会泄漏到循环外
+	ce.enterLoop(node)
+	defer ce.PopLoop()
 
+	for currentIndex := start; (step > 0 && currentIndex <= end) || (step < 0 && currentIndex >= end); currentIndex += step {
 		// 设置循环变量
-		ce.Context.SetVariable(loopVar, currentIndex)
+		ce.SetVariableInScope(loopVar, currentIndex)
 		log.Printf("🔄 循环迭代: %s = %d", loopVar, currentIndex)
 
 		// 执行子节点序列
-		for childIndex := 0; childIndex < len(node.Children); {
-			// 检查控制流信号
-			if ce.Context.ControlFlow.BreakSignal {
-				ce.Context.ResetControlFlow()
-				break
-			}
-			
-			if ce.Context.ControlFlow.ContinueSignal {
-				ce.Context.ResetControlFlow()
-				continue
-			}
-			
-			if err := ce.ExecuteNodeItem(node.Children[childIndex]); err != nil {
-				return err
-			}
-			
-			childIndex++
-			
-			// 操作间添加短暂延迟
-			time.Sleep(500 * time.Millisecond)
+		stop, err := ce.executeLoopIteration(node.Children)
+		if err != nil {
+			return err
 		}
-
-		// 检查continue信号
-		if ce.Context.ControlFlow.ContinueSignal {
-			ce.Context.ResetControlFlow()
-			currentIndex++
-			continue
+		if stop {
+			break
 		}
-
-		currentIndex++
 	}
 
 	log.Printf("🔄 for循环执行完成")
 	return nil
 }
 
-// executeIfCondition 执行条件分支（单层循环结构）
-func (ce *ControlExecutor) executeIfCondition(node *ControlNode) error {
-	log.Printf("❓ 开始执行条件判断")
+// executeLoopIteration 执行一次循环体并处理控制流信号，返回是否需要跳出循环
+func (ce *ControlExecutor) executeLoopIteration(children []NodeItem) (bool, error) {
+	if err := ce.ExecuteNodeItems(children); err != nil {
+		return false, err
+	}
 
-	// 如果有子节点，直接执行（实际应用中应该评估条件表达式）
-	// 这里简化处理，执行if分支
-	if len(node.Children) > 0 {
-		log.Printf("✅ 条件为真，执行if分支")
-		// 使用单层循环结构执行子节点
-		for idx := 0; idx < len(node.Children); idx++ {
-			// 检查控制流信号
-			if ce.Context.ControlFlow.BreakSignal {
-				ce.Context.ResetControlFlow()
-				break
+	// 检查控制流信号，信号指向外层循环时保留信号并跳出当前循环
+	flow := ce.Context.ControlFlow
+	if !flow.BreakSignal && !flow.ContinueSignal {
+		return false, nil
+	}
+	if flow.TargetLoop != "" && flow.TargetLoop != ce.GetCurrentLoop() {
+		return true, nil
+	}
+
+	isBreak := flow.BreakSignal
+	ce.Context.ResetControlFlow()
+	return isBreak, nil
+}
+
+// executeConditionLoop 执行条件循环：while在每次迭代前判断条件，until在每次迭代后判断条件
+func (ce *ControlExecutor) executeConditionLoop(node *ControlNode) error {
+	log.Printf("🔄 开始执行%s循环", node.Type)
+
+	checkBefore := node.Type == ControlTypeWhile
+	maxIterations := node.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	delay := time.Duration(node.Delay) * time.Millisecond
+
+	ce.enterLoop(node)
+	defer ce.PopLoop()
+
+	for iteration := 1; ; iteration++ {
+		if checkBefore {
+			matched, err := ce.EvaluateCondition(node.Condition)
+			if err != nil {
+				return err
 			}
-			
-			if ce.Context.ControlFlow.ContinueSignal {
-				ce.Context.ResetControlFlow()
-				continue
+			if !matched {
+				break
 			}
-			
-			if err := ce.ExecuteNodeItem(node.Children[idx]); err != nil {
+		}
+
+		if iteration > maxIterations {
+			return fmt.Errorf("%s循环超过最大迭代次数 %d，条件: %s", node.Type, maxIterations, node.Condition)
+		}
+
+		if node.Variable != "" {
+			ce.SetVariableInScope(node.Variable, iteration)
+		}
+		log.Printf("🔄 %s循环迭代: 第%d次", node.Type, iteration)
+
+		stop, err := ce.executeLoopIteration(node.Children)
+		if err != nil {
+			return err
+		}
+		if stop {
+			break
+		}
+
+		if !checkBefore {
+			matched, err := ce.EvaluateCondition(node.Condition)
+			if err != nil {
 				return err
 			}
-			
-			// 操作间添加短暂延迟
-			time.Sleep(500 * time.Millisecond)
+			if matched {
+				break
+			}
+		}
+
+		if delay > 0 {
+			time.Sleep(delay)
 		}
-	} else {
-		log.Printf("❌ 条件为假或无子节点，跳过if分支")
 	}
 
-	log.Printf("❓ 条件判断执行完成")
+	log.Printf("🔄 %s循环执行完成", node.Type)
 	return nil
 }
 
-// executeElseCondition 执行else分支（单层循环结构）
-func (ce *ControlExecutor) executeElseCondition(node *ControlNode) error {
-	log.Printf("❓ 开始执行else分支")
-
-	// 检查else分支是否有对应的if前置节点
-	// 这个验证应该在执行时进行，因为解码时无法确定执行顺序
-	
-	// 执行else分支的子节点
-	if len(node.Children) > 0 {
-		log.Printf("✅ 执行else分支")
-		// 使用单层循环结构执行子节点
-		for idx := 0; idx < len(node.Children); idx++ {
-			// 检查控制流信号
-			if ce.Context.ControlFlow.BreakSignal {
-				ce.Context.ResetControlFlow()
-				break
-			}
-			
-			if ce.Context.ControlFlow.ContinueSignal {
-				ce.Context.ResetControlFlow()
-				continue
-			}
-			
-			if err := ce.ExecuteNodeItem(node.Children[idx]); err != nil {
-				return err
-			}
-			
-			// 操作间添加短暂延迟
-			time.Sleep(500 * time.Millisecond)
+// executeTry 执行try节点：子节点失败时执行catch，finally总会执行
+func (ce *ControlExecutor) executeTry(node *ControlNode) error {
+	log.Printf("🧪 开始执行try块")
+
+	err := ce.ExecuteNodeItems(node.Children)
+	if err != nil && len(node.Catch) > 0 {
+		log.Printf("⚠️  try块执行失败，执行catch: %v", err)
+
+		// 错误信息通过变量暴露给catch，如 {{error.message}}
+		errorVar := node.Variable
+		if errorVar == "" {
+			errorVar = "error"
 		}
+		ce.Context.SetVariable(errorVar, map[string]interface{}{
+			"message": err.Error(),
+			"kind":    ClassifyError(err),
+		})
+
+		err = ce.ExecuteNodeItems(node.Catch)
+	}
+
+	if len(node.Finally) > 0 {
+		// finally执行前暂存break/continue信号，避免其被跳过
+		flow := *ce.Context.ControlFlow
+		ce.Context.ResetControlFlow()
+
+		log.Printf("🧪 执行finally")
+		if finallyErr := ce.ExecuteNodeItems(node.Finally); finallyErr != nil {
+			return finallyErr
+		}
+
+		// finally自身未发出信号时恢复之前的信号
+		if !ce.Context.ControlFlow.BreakSignal && !ce.Context.ControlFlow.ContinueSignal {
+			ce.Context.ControlFlow.BreakSignal = flow.BreakSignal
+			ce.Context.ControlFlow.ContinueSignal = flow.ContinueSignal
+			ce.Context.ControlFlow.TargetLoop = flow.TargetLoop
+		}
+	}
+
+	log.Printf("🧪 try块执行完成")
+	return err
+}
+
+// foreachEntry foreach单次迭代的键和值
+type foreachEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// executeForeach 执行foreach遍历，支持列表、映射和匹配选择器的页面元素
+func (ce *ControlExecutor) executeForeach(node *ControlNode) error {
+	log.Printf("🔄 开始执行foreach遍历")
+
+	loopVar := node.Variable
+	if loopVar == "" {
+		loopVar = "item"
+	}
+
+	// 遍历集合在进入循环时确定
+	var entries []foreachEntry
+	var err error
+	if strings.TrimSpace(node.Selector) != "" {
+		entries, err = ce.collectElementEntries(node.Selector)
 	} else {
-		log.Printf("❌ else分支无子节点，跳过")
+		entries, err = ce.collectItemEntries(node.Items)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🔄 遍历参数: 变量=%s, 元素数=%d", loopVar, len(entries))
+
+	ce.enterLoop(node)
+	defer ce.PopLoop()
+
+	for _, entry := range entries {
+		ce.SetVariableInScope(loopVar, entry.Value)
+		if node.Key != "" {
+			ce.SetVariableInScope(node.Key, entry.Key)
+		}
+		log.Printf("🔄 遍历迭代: %s = %v", loopVar, entry.Value)
+
+		stop, err := ce.executeLoopIteration(node.Children)
+		if err != nil {
+			return err
+		}
+		if stop {
+			break
+		}
+	}
+
+	log.Printf("🔄 foreach遍历执行完成")
+	return nil
+}
+
+// collectItemEntries 求值items表达式，列表按下标遍历，映射按键排序遍历
+func (ce *ControlExecutor) collectItemEntries(itemsExpr string) ([]foreachEntry, error) {
+	items, err := EvaluateExpression(itemsExpr, ce.Context)
+	if err != nil {
+		return nil, fmt.Errorf("foreach的items求值失败 '%s': %w", itemsExpr, err)
+	}
+
+	rv := reflect.ValueOf(items)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		entries := make([]foreachEntry, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			entries = append(entries, foreachEntry{Key: i, Value: rv.Index(i).Interface()})
+		}
+		return entries, nil
+
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		entries := make([]foreachEntry, 0, len(keys))
+		for _, key := range keys {
+			entries = append(entries, foreachEntry{Key: key.Interface(), Value: rv.MapIndex(key).Interface()})
+		}
+		return entries, nil
+
+	default:
+		return nil, fmt.Errorf("foreach的items必须是列表或映射: '%s' = %v", itemsExpr, items)
+	}
+}
+
+// collectElementEntries 统计匹配选择器的元素，每个元素以 "选择器 >> nth=下标" 的形式作为循环变量
+func (ce *ControlExecutor) collectElementEntries(rawSelector string) ([]foreachEntry, error) {
+	selector, err := ce.replaceVariables(rawSelector)
+	if err != nil {
+		return nil, fmt.Errorf("foreach的selector模板替换失败: %w", err)
+	}
+	count, err := ce.TaskManager.BrowserManager.CountElements(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]foreachEntry, 0, count)
+	for i := 0; i < count; i++ {
+		entries = append(entries, foreachEntry{Key: i, Value: fmt.Sprintf("%s >> nth=%d", selector, i)})
+	}
+	return entries, nil
+}
+
+// resolveLoopBound 求值循环参数，支持字面量、{{var}}模板和表达式，结果必须为整数
+func (ce *ControlExecutor) resolveLoopBound(name, raw string, defaultValue int) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	// 模板替换后直接作为数值解析，其余非数字字面量按表达式求值
+	var value interface{} = raw
+	if strings.Contains(raw, "{{") {
+		rendered, err := ce.replaceVariables(raw)
+		if err != nil {
+			return 0, fmt.Errorf("for循环的%s模板替换失败: %w", name, err)
+		}
+		value = rendered
+	} else if _, ok := toNumber(raw); !ok {
+		result, err := EvaluateExpression(raw, ce.Context)
+		if err != nil {
+			return 0, fmt.Errorf("for循环的%s求值失败 '%s': %w", name, raw, err)
+		}
+		value = result
+	}
+
+	num, ok := toNumber(value)
+	if !ok {
+		return 0, fmt.Errorf("for循环的%s不是数值: '%s' = %v", name, raw, value)
+	}
+	if num != math.Trunc(num) {
+		return 0, fmt.Errorf("for循环的%s必须是整数: '%s' = %v", name, raw, num)
+	}
+
+	return int(num), nil
+}
+
+// executeSwitch 执行多路分支，表达式只求值一次，执行第一个匹配的case
+func (ce *ControlExecutor) executeSwitch(node *ControlNode) error {
+	value, err := EvaluateExpression(node.Expression, ce.Context)
+	if err != nil {
+		return fmt.Errorf("switch表达式 '%s' 评估失败: %w", node.Expression, err)
+	}
+	log.Printf("🔀 switch表达式 '%s' 评估结果: %v", node.Expression, value)
+
+	var defaultNode *ControlNode
+	for _, child := range node.Children {
+		branch := child.ControlNode
+		if branch.Type == ControlTypeDefault {
+			defaultNode = branch
+			continue
+		}
+
+		matched, err := valuesEqual(value, branch.Value, ce.Context.Strict)
+		if err != nil {
+			return fmt.Errorf("switch表达式 '%s' 与case %v 比较失败: %w", node.Expression, branch.Value, err)
+		}
+		if matched {
+			log.Printf("✅ 匹配case分支: %v", branch.Value)
+			return ce.ExecuteNodeItems(branch.Children)
+		}
+	}
+
+	if defaultNode != nil {
+		log.Printf("✅ 无匹配的case，执行default分支")
+		return ce.ExecuteNodeItems(defaultNode.Children)
+	}
+
+	log.Printf("❌ 无匹配的case且无default分支，跳过switch")
+	return nil
+}
+
+// executeIfCondition 执行条件分支（if或elif），返回条件是否成立
+func (ce *ControlExecutor) executeIfCondition(node *ControlNode) (bool, error) {
+	log.Printf("❓ 开始执行条件判断")
+
+	matched, err := ce.EvaluateCondition(node.Condition)
+	if err != nil {
+		return false, err
+	}
+
+	if !matched {
+		log.Printf("❌ 条件为假，跳过if分支")
+		return false, nil
+	}
+
+	log.Printf("✅ 条件为真，执行if分支")
+	if err := ce.ExecuteNodeItems(node.Children); err != nil {
+		return true, err
+	}
+
+	log.Printf("❓ 条件判断执行完成")
+	return true, nil
+}
+
+// executeElseCondition 执行else分支，仅在前一个if条件为假时执行
+func (ce *ControlExecutor) executeElseCondition(node *ControlNode, ifMatched bool) error {
+	if ifMatched {
+		log.Printf("❌ if条件已成立，跳过else分支")
+		return nil
+	}
+
+	log.Printf("✅ 执行else分支")
+	if err := ce.ExecuteNodeItems(node.Children); err != nil {
+		return err
 	}
 
 	log.Printf("❓ else分支执行完成")
 	return nil
 }
 
+// extractText 提取文本中匹配正则表达式的部分：只有一个捕获组时为该组的文本，有多个捕获组时为列表，没有捕获组时为整个匹配
+func extractText(text, pattern string) (interface{}, error) {
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	groups := captureGroups(re, text)
+	if groups == nil {
+		return nil, fmt.Errorf("文本 '%s' 不匹配正则表达式 '%s'", text, pattern)
+	}
+	if len(groups) == 1 {
+		log.Printf("🔍 正则提取结果: '%s'", groups[0])
+		return groups[0], nil
+	}
+	log.Printf("🔍 正则提取结果: %v", groups)
+	return groups, nil
+}
+
 // EvaluateCondition 评估条件表达式
 func (ce *ControlExecutor) EvaluateCondition(conditionExpr string) (bool, error) {
 	if conditionExpr == "" {
@@ -373,7 +759,7 @@ func (ce *ControlExecutor) EvaluateCondition(conditionExpr string) (bool, error)
 
 	result, err := EvaluateBoolean(conditionExpr, ce.Context)
 	if err != nil {
-		return false, fmt.Errorf("条件表达式评估失败: %w", err)
+		return false, fmt.Errorf("条件表达式 '%s' 评估失败: %w", conditionExpr, err)
 	}
 
 	log.Printf("❓ 条件表达式 '%s' 评估结果: %v", conditionExpr, result)
@@ -405,12 +791,24 @@ func (ce *ControlExecutor) PrintVariables() {
 
 // 嵌套循环管理方法
 
+// enterLoop 为一次循环执行生成唯一ID并推入循环栈，同时记录循环标签
+func (ce *ControlExecutor) enterLoop(node *ControlNode) {
+	ce.loopCounter++
+	loopID := fmt.Sprintf("%s#%d", node.Type, ce.loopCounter)
+	if node.Label != "" {
+		ce.loopLabels[loopID] = node.Label
+	}
+	ce.PushLoop(loopID)
+}
+
 // PushLoop 推入循环到栈
 func (ce *ControlExecutor) PushLoop(loopID string) {
 	ce.LoopStack = append(ce.LoopStack, loopID)
 	ce.Context.ControlFlow.CurrentLoop = loopID
 	// 创建新的作用域
-	ce.ScopeVariables[loopID] = make(map[string]any)
+	scope := make(map[string]any)
+	ce.ScopeVariables[loopID] = scope
+	ce.Context.PushScope(scope)
 }
 
 // PopLoop 从栈中弹出循环
@@ -419,6 +817,8 @@ func (ce *ControlExecutor) PopLoop() {
 		// 移除当前作用域
 		lastLoop := ce.LoopStack[len(ce.LoopStack)-1]
 		delete(ce.ScopeVariables, lastLoop)
+		delete(ce.loopLabels, lastLoop)
+		ce.Context.PopScope()
 
 		ce.LoopStack = ce.LoopStack[:len(ce.LoopStack)-1]
 
@@ -444,49 +844,76 @@ func (ce *ControlExecutor) IsInLoop() bool {
 	return len(ce.LoopStack) > 0
 }
 
-// SetVariableInScope 在作用域中设置变量
+// SetVariableInScope 在当前循环作用域中定义变量，不在循环中时写入全局上下文
 func (ce *ControlExecutor) SetVariableInScope(variableName string, value any) {
 	if ce.IsInLoop() {
 		currentLoop := ce.GetCurrentLoop()
 		if scope, exists := ce.ScopeVariables[currentLoop]; exists {
 			scope[variableName] = value
+			return
 		}
 	}
-	// 同时设置到全局上下文
 	ce.Context.SetVariable(variableName, value)
 }
 
-// GetVariableFromScope 从作用域获取变量
+// GetVariableFromScope 从作用域获取变量，由内向外查找各层循环作用域，最后回退到全局上下文
 func (ce *ControlExecutor) GetVariableFromScope(variableName string) any {
-	// 首先检查当前作用域
-	if ce.IsInLoop() {
-		currentLoop := ce.GetCurrentLoop()
-		if scope, exists := ce.ScopeVariables[currentLoop]; exists {
+	for i := len(ce.LoopStack) - 1; i >= 0; i-- {
+		if scope, exists := ce.ScopeVariables[ce.LoopStack[i]]; exists {
 			if val, ok := scope[variableName]; ok {
 				return val
 			}
 		}
 	}
-	// 回退到全局上下文
-	return ce.Context.GetVariable(variableName)
+	return ce.Context.Variables[variableName]
 }
 
-// HandleBreak 处理break信号
-func (ce *ControlExecutor) HandleBreak() {
-	if ce.IsInLoop() {
-		ce.Context.SignalBreak()
-		log.Printf("🛑 发送break信号，退出当前循环: %s", ce.GetCurrentLoop())
-	} else {
+// findLoop 查找信号的目标循环，label为空时返回最内层循环
+func (ce *ControlExecutor) findLoop(label string) (string, error) {
+	if !ce.IsInLoop() {
+		return "", fmt.Errorf("不在循环中")
+	}
+	if label == "" {
+		return ce.GetCurrentLoop(), nil
+	}
+	for i := len(ce.LoopStack) - 1; i >= 0; i-- {
+		if ce.loopLabels[ce.LoopStack[i]] == label {
+			return ce.LoopStack[i], nil
+		}
+	}
+	return "", fmt.Errorf("未找到标签为 %s 的外层循环", label)
+}
+
+// HandleBreak 处理break信号，target为目标循环的标签，为空时退出最内层循环
+func (ce *ControlExecutor) HandleBreak(target string) error {
+	if !ce.IsInLoop() {
 		log.Printf("⚠️  break信号在循环外无效")
+		return nil
 	}
+
+	loopID, err := ce.findLoop(target)
+	if err != nil {
+		return fmt.Errorf("break失败: %w", err)
+	}
+	ce.Context.SignalBreak()
+	ce.Context.ControlFlow.TargetLoop = loopID
+	log.Printf("🛑 发送break信号，退出循环: %s", loopID)
+	return nil
 }
 
-// HandleContinue 处理continue信号
-func (ce *ControlExecutor) HandleContinue() {
-	if ce.IsInLoop() {
-		ce.Context.SignalContinue()
-		log.Printf("⏭️  发送continue信号，跳过当前循环剩余部分: %s", ce.GetCurrentLoop())
-	} else {
+// HandleContinue 处理continue信号，target为目标循环的标签，为空时作用于最内层循环
+func (ce *ControlExecutor) HandleContinue(target string) error {
+	if !ce.IsInLoop() {
 		log.Printf("⚠️  continue信号在循环外无效")
+		return nil
 	}
+
+	loopID, err := ce.findLoop(target)
+	if err != nil {
+		return fmt.Errorf("continue失败: %w", err)
+	}
+	ce.Context.SignalContinue()
+	ce.Context.ControlFlow.TargetLoop = loopID
+	log.Printf("⏭️  发送continue信号，跳过循环剩余部分: %s", loopID)
+	return nil
 }
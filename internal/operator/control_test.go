@@ -0,0 +1,798 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// traceLog 记录测试中trace()的调用，用于观察节点的执行顺序
+var (
+	traceMu  sync.Mutex
+	traceLog []string
+)
+
+func init() {
+	// 测试不连接浏览器，无需在节点之间等待
+	actionInterval = 0
+
+	RegisterFunction("trace", func(args []interface{}) (interface{}, error) {
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = formatVariable(arg)
+		}
+		traceMu.Lock()
+		traceLog = append(traceLog, strings.Join(parts, ":"))
+		traceMu.Unlock()
+		return false, nil
+	})
+}
+
+// traceStep 匹配测试YAML中的 "- trace: 表达式" 简写
+var traceStep = regexp.MustCompile(`(?m)^(\s*)- trace: (.*)$`)
+
+// expandTraceSteps 将测试YAML中的 "- trace: 表达式" 展开为条件调用trace()的if节点；
+// trace()总是返回假，子节点不会执行，只用于满足if必须包含子节点的验证
+func expandTraceSteps(source string) string {
+	return traceStep.ReplaceAllStringFunc(source, func(line string) string {
+		match := traceStep.FindStringSubmatch(line)
+		condition := strconv.Quote("trace(" + match[2] + ")")
+		return fmt.Sprintf("%s- {type: if, condition: %s, children: [{type: break}]}", match[1], condition)
+	})
+}
+
+// parseTestNodes 解析测试用的节点序列，支持 "- trace: 表达式" 简写
+func parseTestNodes(t *testing.T, source string) []NodeItem {
+	t.Helper()
+	source = expandTraceSteps(source)
+
+	var items []NodeItem
+	if err := yaml.Unmarshal([]byte(source), &items); err != nil {
+		t.Fatalf("解析测试节点失败: %v", err)
+	}
+	return items
+}
+
+// resetTrace 清空trace记录
+func resetTrace() {
+	traceMu.Lock()
+	traceLog = nil
+	traceMu.Unlock()
+}
+
+// takeTrace 取出trace记录
+func takeTrace() []string {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	trace := traceLog
+	traceLog = nil
+	return trace
+}
+
+// newTestExecutor 创建不连接浏览器的执行器，页面操作都会以"页面未初始化"失败
+func newTestExecutor(variables map[string]interface{}) *ControlExecutor {
+	executor := NewControlExecutor(NewTaskManager(&BrowserManager{}))
+	for name, value := range variables {
+		executor.SetVariable(name, value)
+	}
+	return executor
+}
+
+func TestExecuteNodeItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		nodes     string
+		want      []string
+		wantErr   string // 期望错误信息中包含的内容，为空表示不期望出错
+	}{
+		{
+			name:      "if条件成立时跳过elif和else",
+			variables: map[string]interface{}{"x": 10},
+			nodes: `
+- type: if
+  condition: "x > 5"
+  children:
+    - trace: 'if'
+- type: elif
+  condition: "x > 1"
+  children:
+    - trace: 'elif'
+- type: else
+  children:
+    - trace: 'else'
+`,
+			want: []string{"if"},
+		},
+		{
+			name:      "执行第一个成立的elif",
+			variables: map[string]interface{}{"x": 3},
+			nodes: `
+- type: if
+  condition: "x > 5"
+  children:
+    - trace: 'if'
+- type: else_if
+  condition: "x > 1"
+  children:
+    - trace: 'elif'
+- type: else
+  children:
+    - trace: 'else'
+`,
+			want: []string{"elif"},
+		},
+		{
+			name:      "条件都不成立时执行else",
+			variables: map[string]interface{}{"x": 0},
+			nodes: `
+- type: if
+  condition: "x > 5"
+  children:
+    - trace: 'if'
+- type: else
+  children:
+    - trace: 'else'
+`,
+			want: []string{"else"},
+		},
+		{
+			name:      "if条件成立时跳过else",
+			variables: map[string]interface{}{"x": 10},
+			nodes: `
+- type: if
+  condition: "x > 5"
+  children:
+    - trace: 'if'
+- type: else
+  children:
+    - trace: 'else'
+- trace: 'after'
+`,
+			want: []string{"if", "after"},
+		},
+		{
+			name:      "switch匹配case",
+			variables: map[string]interface{}{"role": "编辑"},
+			nodes: `
+- type: switch
+  expression: "role"
+  children:
+    - type: case
+      value: "管理员"
+      children:
+        - trace: 'admin'
+    - type: case
+      value: "编辑"
+      children:
+        - trace: 'editor'
+    - type: default
+      children:
+        - trace: 'default'
+`,
+			want: []string{"editor"},
+		},
+		{
+			name:      "switch无匹配时执行default",
+			variables: map[string]interface{}{"count": "3"},
+			nodes: `
+- type: switch
+  expression: "count + 1"
+  children:
+    - type: case
+      value: 3
+      children:
+        - trace: 'three'
+    - type: default
+      children:
+        - trace: 'default'
+`,
+			want: []string{"default"},
+		},
+		{
+			name: "for循环包含结束值",
+			nodes: `
+- type: for
+  from: 1
+  to: 3
+  children:
+    - trace: i
+`,
+			want: []string{"1", "2", "3"},
+		},
+		{
+			name:      "for循环的边界为表达式且步长为负数",
+			variables: map[string]interface{}{"items": []interface{}{"a", "b", "c", "d"}},
+			nodes: `
+- type: for
+  variable: n
+  from: "len(items) - 1"
+  to: 0
+  step: -2
+  children:
+    - trace: n, items[n]
+`,
+			want: []string{"3:d", "1:b"},
+		},
+		{
+			name:      "for循环的边界为模板",
+			variables: map[string]interface{}{"total": "2"},
+			nodes: `
+- type: for
+  to: "{{total}}"
+  children:
+    - trace: i
+`,
+			want: []string{"0", "1", "2"},
+		},
+		{
+			name:      "for循环的边界不是整数",
+			variables: map[string]interface{}{"total": 2.5},
+			nodes: `
+- type: for
+  to: "total"
+  children:
+    - trace: i
+`,
+			wantErr: "必须是整数",
+		},
+		{
+			name:      "foreach遍历列表",
+			variables: map[string]interface{}{"products": []interface{}{"手机", "电脑"}},
+			nodes: `
+- type: foreach
+  items: "products"
+  variable: product
+  key: index
+  children:
+    - trace: index, product
+`,
+			want: []string{"0:手机", "1:电脑"},
+		},
+		{
+			name:      "foreach按键排序遍历映射",
+			variables: map[string]interface{}{"prices": map[string]interface{}{"b": 2, "a": 1}},
+			nodes: `
+- type: foreach
+  items: "prices"
+  key: name
+  children:
+    - trace: name, item
+`,
+			want: []string{"a:1", "b:2"},
+		},
+		{
+			name:      "foreach的items不是集合",
+			variables: map[string]interface{}{"name": "iPhone"},
+			nodes: `
+- type: foreach
+  items: "name"
+  children:
+    - trace: item
+`,
+			wantErr: "必须是列表或映射",
+		},
+		{
+			name: "while在每次迭代前判断条件",
+			nodes: `
+- type: while
+  condition: "(n ?? 0) < 3"
+  variable: n
+  children:
+    - trace: n
+`,
+			want: []string{"1", "2", "3"},
+		},
+		{
+			name: "until至少执行一次",
+			nodes: `
+- type: do_until
+  condition: "true"
+  children:
+    - trace: 'body'
+`,
+			want: []string{"body"},
+		},
+		{
+			name: "条件循环超过最大迭代次数",
+			nodes: `
+- type: while
+  condition: "true"
+  max_iterations: 2
+  children:
+    - trace: 'body'
+`,
+			want:    []string{"body", "body"},
+			wantErr: "超过最大迭代次数 2",
+		},
+		{
+			name: "循环变量不会泄漏到循环外",
+			nodes: `
+- type: for
+  to: 1
+  children:
+    - trace: i
+- trace: i ?? 'undefined'
+`,
+			want: []string{"0", "1", "undefined"},
+		},
+		{
+			name:      "内层循环可以读取外层循环变量，同名变量遮蔽外层",
+			variables: map[string]interface{}{"i": "global"},
+			nodes: `
+- type: for
+  from: 1
+  to: 2
+  children:
+    - type: foreach
+      items: "['a']"
+      children:
+        - trace: i, item
+- trace: i
+`,
+			want: []string{"1:a", "2:a", "global"},
+		},
+		{
+			name: "break退出最内层循环",
+			nodes: `
+- type: for
+  from: 1
+  to: 5
+  children:
+    - type: if
+      condition: "i == 3"
+      children:
+        - type: break
+    - trace: i
+`,
+			want: []string{"1", "2"},
+		},
+		{
+			name: "continue跳过本次迭代的剩余部分",
+			nodes: `
+- type: for
+  from: 1
+  to: 4
+  children:
+    - type: if
+      condition: "i % 2 == 0"
+      children:
+        - type: continue
+    - trace: i
+`,
+			want: []string{"1", "3"},
+		},
+		{
+			name: "带标签的break退出外层循环",
+			nodes: `
+- type: for
+  label: outer
+  from: 1
+  to: 3
+  children:
+    - type: foreach
+      items: "['a', 'b']"
+      children:
+        - type: if
+          condition: "i == 2 && item == 'b'"
+          children:
+            - type: break
+              target: outer
+        - trace: i, item
+    - trace: 'after', i
+`,
+			want: []string{"1:a", "1:b", "after:1", "2:a"},
+		},
+		{
+			name: "带标签的continue继续外层循环的下一次迭代",
+			nodes: `
+- type: for
+  label: rows
+  from: 1
+  to: 2
+  children:
+    - type: for
+      variable: j
+      from: 1
+      to: 3
+      children:
+        - type: if
+          condition: "j == 2"
+          children:
+            - type: continue
+              target: rows
+        - trace: i, j
+    - trace: 'row end', i
+`,
+			want: []string{"1:1", "2:1"},
+		},
+		{
+			name: "try失败时执行catch并通过变量获取错误信息",
+			nodes: `
+- type: try
+  children:
+    - type: click
+      selector: "#missing"
+    - trace: 'unreachable'
+  catch:
+    - trace: error.kind, error.message =~ '页面未初始化'
+  finally:
+    - trace: 'finally'
+- trace: 'after'
+`,
+			want: []string{"other:true", "finally", "after"},
+		},
+		{
+			name: "try成功时跳过catch",
+			nodes: `
+- type: try
+  variable: failure
+  children:
+    - trace: 'body'
+  catch:
+    - trace: failure.message
+`,
+			want: []string{"body"},
+		},
+		{
+			name: "没有catch时错误在finally执行后继续传递",
+			nodes: `
+- type: try
+  children:
+    - type: hover
+      selector: "#menu"
+  finally:
+    - trace: 'finally'
+- trace: 'after'
+`,
+			want:    []string{"finally"},
+			wantErr: "操作失败: hover",
+		},
+		{
+			name: "catch中的错误继续传递",
+			nodes: `
+- type: try
+  children:
+    - type: click
+      selector: "#a"
+  catch:
+    - type: click
+      selector: "#b"
+      error_message: "catch中的操作失败"
+`,
+			wantErr: "catch中的操作失败",
+		},
+		{
+			name: "try中的break在执行finally后退出循环",
+			nodes: `
+- type: for
+  from: 1
+  to: 3
+  children:
+    - type: try
+      children:
+        - type: if
+          condition: "i == 2"
+          children:
+            - type: break
+      finally:
+        - trace: 'finally', i
+    - trace: i
+`,
+			want: []string{"finally:1", "1", "finally:2"},
+		},
+		{
+			name: "循环外的break被忽略",
+			nodes: `
+- type: break
+- trace: 'after'
+`,
+			want: []string{"after"},
+		},
+		{
+			name: "trace简写中的表达式可以包含双引号",
+			nodes: `
+- trace: "it's", 'say "hi"'
+`,
+			want: []string{`it's:say "hi"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := parseTestNodes(t, tt.nodes)
+			if err := ValidateNodeItems(items); err != nil {
+				t.Fatalf("验证节点失败: %v", err)
+			}
+
+			resetTrace()
+			err := newTestExecutor(tt.variables).ExecuteNodeItems(items)
+			got := takeTrace()
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("执行返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("执行顺序 = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNodeItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   string
+		wantErr string // 为空表示期望验证通过
+	}{
+		{
+			name: "else前缺少if",
+			nodes: `
+- type: else
+  children:
+    - type: click
+      selector: "#a"
+`,
+			wantErr: "else分支前缺少对应的if节点",
+		},
+		{
+			name: "if与else之间隔着其他节点",
+			nodes: `
+- type: if
+  condition: "true"
+  children:
+    - type: click
+      selector: "#a"
+- type: click
+  selector: "#b"
+- type: elif
+  condition: "true"
+  children:
+    - type: click
+      selector: "#c"
+`,
+			wantErr: "elif分支前缺少对应的if节点",
+		},
+		{
+			name: "case只能出现在switch中",
+			nodes: `
+- type: case
+  value: 1
+  children:
+    - type: click
+      selector: "#a"
+`,
+			wantErr: "case分支只能出现在switch中",
+		},
+		{
+			name: "default必须是最后一个分支",
+			nodes: `
+- type: switch
+  expression: "x"
+  children:
+    - type: default
+      children:
+        - type: click
+          selector: "#a"
+    - type: case
+      value: 1
+      children:
+        - type: click
+          selector: "#b"
+`,
+			wantErr: "default分支必须是switch的最后一个子节点",
+		},
+		{
+			name: "for循环的step为0",
+			nodes: `
+- type: for
+  to: 3
+  step: 0
+  children:
+    - type: click
+      selector: "#a"
+`,
+			wantErr: "step不能为0",
+		},
+		{
+			name: "foreach不能同时提供items和selector",
+			nodes: `
+- type: foreach
+  items: "list"
+  selector: ".item"
+  children:
+    - type: click
+      selector: "#a"
+`,
+			wantErr: "只能提供items或selector其中之一",
+		},
+		{
+			name: "break引用外层循环的标签",
+			nodes: `
+- type: for
+  label: outer
+  to: 3
+  children:
+    - type: while
+      condition: "true"
+      children:
+        - type: break
+          target: outer
+`,
+		},
+		{
+			name: "break引用未知的循环标签",
+			nodes: `
+- type: for
+  label: outer
+  to: 3
+  children:
+    - type: break
+      target: inner
+`,
+			wantErr: "未知的循环标签: inner",
+		},
+		{
+			name: "循环标签与外层重复",
+			nodes: `
+- type: for
+  label: loop
+  to: 3
+  children:
+    - type: foreach
+      label: loop
+      items: "list"
+      children:
+        - type: click
+          selector: "#a"
+`,
+			wantErr: "循环标签 loop 与外层循环重复",
+		},
+		{
+			name: "非循环节点不能设置标签",
+			nodes: `
+- type: if
+  label: check
+  condition: "true"
+  children:
+    - type: click
+      selector: "#a"
+`,
+			wantErr: "只有循环节点可以设置label",
+		},
+		{
+			name: "并行分支中的break不能退出分支外的循环",
+			nodes: `
+- type: for
+  to: 3
+  children:
+    - type: parallel
+      children:
+        - type: branch
+          children:
+            - type: if
+              condition: "i == 2"
+              children:
+                - type: break
+`,
+			wantErr: "break不能作用于并行分支之外的循环",
+		},
+		{
+			name: "并行分支中的continue不能引用分支外的循环标签",
+			nodes: `
+- type: foreach
+  label: rows
+  items: "list"
+  children:
+    - type: parallel
+      children:
+        - type: branch
+          children:
+            - type: continue
+              target: rows
+`,
+			wantErr: "未知的循环标签: rows",
+		},
+		{
+			name: "并行分支中的break可以退出分支内的循环",
+			nodes: `
+- type: for
+  to: 3
+  children:
+    - type: parallel
+      children:
+        - type: branch
+          children:
+            - type: while
+              condition: "true"
+              children:
+                - type: break
+`,
+		},
+		{
+			name: "try必须提供catch或finally",
+			nodes: `
+- type: try
+  children:
+    - type: click
+      selector: "#a"
+`,
+			wantErr: "try必须提供catch或finally其中至少一个",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNodeItems(parseTestNodes(t, tt.nodes))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("验证返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNodeItemUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    *ControlNode
+		wantErr string
+	}{
+		{
+			name: "for循环的边界为数字",
+			json: `{"type": "for", "from": 1, "to": 3, "step": -0.5, "children": [{"type": "click", "selector": "#a"}]}`,
+			want: &ControlNode{Type: ControlTypeForLoop, From: "1", To: "3", Step: "-0.5"},
+		},
+		{
+			name: "for循环的边界为表达式",
+			json: `{"type": "for", "from": "len(items) - 1", "to": "{{total}}", "children": [{"type": "click", "selector": "#a"}]}`,
+			want: &ControlNode{Type: ControlTypeForLoop, From: "len(items) - 1", To: "{{total}}"},
+		},
+		{
+			name:    "控制节点的解析错误不会被当作操作",
+			json:    `{"type": "for", "to": [1, 2], "children": []}`,
+			wantErr: "无法解析for节点",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var item NodeItem
+			err := json.Unmarshal([]byte(tt.json), &item)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析返回错误: %v", err)
+			}
+			if item.ControlNode == nil {
+				t.Fatalf("解析结果不是控制节点: %+v", item.Action)
+			}
+			got := *item.ControlNode
+			got.Children = nil
+			if !reflect.DeepEqual(&got, tt.want) {
+				t.Errorf("解析结果 = %+v，期望 %+v", got, *tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package operator
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// validateEvaluate 验证evaluate操作的参数
+func validateEvaluate(action *Action) error {
+	if action.Type != ActionEvaluate {
+		if action.Script != "" {
+			return fmt.Errorf("%s操作不支持script参数", action.Type)
+		}
+		return nil
+	}
+	if strings.TrimSpace(action.Script) == "" {
+		return fmt.Errorf("evaluate操作需要提供script参数")
+	}
+	return nil
+}
+
+// evaluateScript 执行evaluate操作：当前可见的变量作为参数传入脚本，结果保留列表、映射等结构存入output_key
+func (ce *ControlExecutor) evaluateScript(action *Action, selector string) error {
+	variables := scriptValue(ce.Context.visibleVariables())
+
+	result, err := ce.TaskManager.BrowserManager.Evaluate(selector, action.Script, variables)
+	if err != nil {
+		return err
+	}
+	result = scriptValue(result)
+
+	log.Printf("📜 脚本执行结果: %s", formatVariable(result))
+	if action.OutputKey != "" {
+		ce.Context.SetVariable(action.OutputKey, result)
+		log.Printf("📋 脚本结果已存储到变量: %s", action.OutputKey)
+	}
+	return nil
+}
+
+// scriptValue 将值转换为与表达式一致的结构：数值统一为float64，列表为[]interface{}，映射为map[string]interface{}；
+// 传给脚本的变量和脚本的返回值都经过转换，无法序列化的值按字符串处理
+func scriptValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, float64, time.Time:
+		return v
+	case *url.URL:
+		return v.String()
+	case *big.Int:
+		return v.String()
+	case error:
+		return v.Error()
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		number, _ := toNumber(value)
+		return number
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = scriptValue(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Map:
+		result := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			result[fmt.Sprint(iter.Key().Interface())] = scriptValue(iter.Value().Interface())
+		}
+		return result
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		return scriptValue(rv.Elem().Interface())
+	}
+	return formatVariable(value)
+}
@@ -1,9 +1,14 @@
 package operator
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"math"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -14,29 +19,45 @@ type Expression interface {
 
 // 表达式类型
 const (
-	ExprTypeLiteral   = "literal"
-	ExprTypeVariable  = "variable"
-	ExprTypeBinary    = "binary"
-	ExprTypeUnary     = "unary"
+	ExprTypeLiteral  = "literal"
+	ExprTypeVariable = "variable"
+	ExprTypeBinary   = "binary"
+	ExprTypeUnary    = "unary"
 )
 
 // BinaryOperator 二元操作符
 const (
-	OpEQ = "=="
-	OpNE = "!="
-	OpGT = ">"
-	OpGE = ">="
-	OpLT = "<"
-	OpLE = "<="
-	OpAnd = "&&"
-	OpOr = "||"
+	OpEQ       = "=="
+	OpNE       = "!="
+	OpGT       = ">"
+	OpGE       = ">="
+	OpLT       = "<"
+	OpLE       = "<="
+	OpAnd      = "&&"
+	OpOr       = "||"
+	OpAdd      = "+"
+	OpSub      = "-"
+	OpMul      = "*"
+	OpDiv      = "/"
+	OpMod      = "%"
+	OpIn       = "in"
+	OpNotIn    = "not in"
+	OpPipe     = "|"
+	OpMatch    = "=~"
+	OpNotMatch = "!~"
+	OpCoalesce = "??"
+	OpTernary  = "?"
 )
 
 // UnaryOperator 一元操作符
 const (
 	OpNot = "!"
+	OpNeg = "-"
 )
 
+// ErrUndefinedVariable 引用了未定义的变量
+var ErrUndefinedVariable = errors.New("变量未定义")
+
 // LiteralExpression 字面量表达式
 type LiteralExpression struct {
 	Value interface{}
@@ -52,9 +73,9 @@ type VariableExpression struct {
 }
 
 func (e *VariableExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
-	val := ctx.GetVariable(e.Name)
-	if val == nil {
-		return nil, fmt.Errorf("变量未定义: %s", e.Name)
+	val, exists := ctx.LookupVariable(e.Name)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUndefinedVariable, e.Name)
 	}
 	return val, nil
 }
@@ -72,6 +93,11 @@ func (e *BinaryExpression) Evaluate(ctx *ExecutionContext) (interface{}, error)
 		return nil, err
 	}
 
+	// && 和 || 短路求值，右侧只在需要时求值
+	if e.Operator == OpAnd || e.Operator == OpOr {
+		return e.evaluateLogical(ctx, leftVal)
+	}
+
 	rightVal, err := e.Right.Evaluate(ctx)
 	if err != nil {
 		return nil, err
@@ -79,26 +105,48 @@ func (e *BinaryExpression) Evaluate(ctx *ExecutionContext) (interface{}, error)
 
 	switch e.Operator {
 	case OpEQ:
-		return equal(leftVal, rightVal), nil
+		return valuesEqual(leftVal, rightVal, ctx.Strict)
 	case OpNE:
-		return !equal(leftVal, rightVal), nil
-	case OpGT:
-		return greaterThan(leftVal, rightVal), nil
-	case OpGE:
-		return greaterThanOrEqual(leftVal, rightVal), nil
-	case OpLT:
-		return lessThan(leftVal, rightVal), nil
-	case OpLE:
-		return lessThanOrEqual(leftVal, rightVal), nil
-	case OpAnd:
-		return logicalAnd(leftVal, rightVal), nil
-	case OpOr:
-		return logicalOr(leftVal, rightVal), nil
+		eq, err := valuesEqual(leftVal, rightVal, ctx.Strict)
+		return !eq, err
+	case OpGT, OpGE, OpLT, OpLE:
+		return compare(e.Operator, leftVal, rightVal, ctx.Strict)
+	case OpIn:
+		return containsValue(rightVal, leftVal, ctx.Strict)
+	case OpNotIn:
+		found, err := containsValue(rightVal, leftVal, ctx.Strict)
+		return !found, err
+	case OpMatch:
+		return regexMatch(leftVal, rightVal, ctx.Strict)
+	case OpNotMatch:
+		matched, err := regexMatch(leftVal, rightVal, ctx.Strict)
+		return !matched, err
+	case OpAdd:
+		return add(leftVal, rightVal, ctx.Strict)
+	case OpSub, OpMul, OpDiv, OpMod:
+		return arithmetic(e.Operator, leftVal, rightVal, ctx.Strict)
 	default:
 		return nil, fmt.Errorf("不支持的操作符: %s", e.Operator)
 	}
 }
 
+// evaluateLogical 短路求值 && 和 ||
+func (e *BinaryExpression) evaluateLogical(ctx *ExecutionContext, leftVal interface{}) (interface{}, error) {
+	left, err := truthy(e.Operator, leftVal, ctx.Strict)
+	if err != nil {
+		return nil, err
+	}
+	if (e.Operator == OpAnd && !left) || (e.Operator == OpOr && left) {
+		return left, nil
+	}
+
+	rightVal, err := e.Right.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(e.Operator, rightVal, ctx.Strict)
+}
+
 // UnaryExpression 一元表达式
 type UnaryExpression struct {
 	Operator string
@@ -113,22 +161,167 @@ func (e *UnaryExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
 
 	switch e.Operator {
 	case OpNot:
-		return logicalNot(val), nil
+		result, err := truthy(OpNot, val, ctx.Strict)
+		return !result, err
+	case OpNeg:
+		num, ok := toNumber(val)
+		if !ok || (ctx.Strict && valueKind(val) != kindNumber) {
+			return nil, fmt.Errorf("无法对%s %s 取负", kindName(val), describeValue(val))
+		}
+		return -num, nil
 	default:
 		return nil, fmt.Errorf("不支持的一元操作符: %s", e.Operator)
 	}
 }
 
+// MemberExpression 成员访问表达式，如 user.name
+type MemberExpression struct {
+	Object Expression
+	Name   string
+}
+
+func (e *MemberExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
+	object, err := e.Object.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return memberOf(object, e.Name, describeExpression(e))
+}
+
+// IndexExpression 下标访问表达式，如 items[0]、row["价格"]
+type IndexExpression struct {
+	Object Expression
+	Index  Expression
+}
+
+func (e *IndexExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
+	object, err := e.Object.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index, err := e.Index.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return indexOf(object, index, describeExpression(e))
+}
+
+// ListExpression 列表字面量，如 [1, 2, 3]
+type ListExpression struct {
+	Items []Expression
+}
+
+func (e *ListExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
+	list := make([]interface{}, len(e.Items))
+	for i, item := range e.Items {
+		val, err := item.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = val
+	}
+	return list, nil
+}
+
+// MapExpression 映射字面量，如 {name: 'iPhone', 'price': 4799}
+type MapExpression struct {
+	Keys   []string
+	Values []Expression
+}
+
+func (e *MapExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
+	fields := make(map[string]interface{}, len(e.Keys))
+	for i, key := range e.Keys {
+		val, err := e.Values[i].Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = val
+	}
+	return fields, nil
+}
+
+// CallExpression 函数调用表达式
+type CallExpression struct {
+	Name string
+	Args []Expression
+}
+
+func (e *CallExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
+	fn, exists := LookupFunction(e.Name)
+	if !exists {
+		return nil, fmt.Errorf("未知的函数: %s", e.Name)
+	}
+
+	args := make([]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		val, err := arg.Evaluate(ctx)
+		// default的第一个参数允许引用未定义的变量，此时返回备用值
+		if err != nil && !(e.Name == FunctionDefault && i == 0 && errors.Is(err, ErrUndefinedVariable)) {
+			return nil, err
+		}
+		args[i] = val
+	}
+
+	result, err := fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("函数 %s 调用失败: %w", e.Name, err)
+	}
+	return result, nil
+}
+
+// ConditionalExpression 三元表达式，如 count > 0 ? '有货' : '缺货'，只求值被选中的分支
+type ConditionalExpression struct {
+	Condition Expression
+	Then      Expression
+	Else      Expression
+}
+
+func (e *ConditionalExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
+	val, err := e.Condition.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched, err := truthy(OpTernary, val, ctx.Strict)
+	if err != nil {
+		return nil, err
+	}
+	if matched {
+		return e.Then.Evaluate(ctx)
+	}
+	return e.Else.Evaluate(ctx)
+}
+
+// CoalesceExpression 空值合并表达式，如 searchTerm ?? 'laptop'：左侧未定义或为空值时取右侧的值
+type CoalesceExpression struct {
+	Left  Expression
+	Right Expression
+}
+
+func (e *CoalesceExpression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
+	val, err := e.Left.Evaluate(ctx)
+	if err != nil && !errors.Is(err, ErrUndefinedVariable) {
+		return nil, err
+	}
+	if err == nil && val != nil {
+		return val, nil
+	}
+	return e.Right.Evaluate(ctx)
+}
+
 // Parser 表达式解析器
 type Parser struct {
 	tokens []Token
 	pos    int
+	end    int   // 表达式结束位置的列号
+	err    error // 词法分析错误，在Parse时返回
 }
 
 // Token 词法单元
 type Token struct {
 	Type  TokenType
 	Value string
+	Pos   int // 在表达式中的列号，从1开始
 }
 
 // TokenType 词法单元类型
@@ -141,60 +334,174 @@ const (
 	TokenOperator
 	TokenParenLeft
 	TokenParenRight
+	TokenComma
+	TokenBracketLeft
+	TokenBracketRight
+	TokenBraceLeft
+	TokenBraceRight
+	TokenColon
+	TokenDot
 	TokenEOF
 )
 
+// operators 支持的操作符，多字符操作符在前以优先匹配
+var operators = []string{OpEQ, OpNE, OpMatch, OpNotMatch, OpCoalesce, OpGE, OpLE, OpAnd, OpOr, OpGT, OpLT, OpNot, OpAdd, OpSub, OpMul, OpDiv, OpMod, OpTernary, OpPipe}
+
+// literalKeywords 布尔值和空值字面量
+var literalKeywords = map[string]interface{}{
+	"true":  true,
+	"false": false,
+	"null":  nil,
+}
+
 // NewParser 创建新的解析器
 func NewParser(expression string) *Parser {
-	tokens := tokenize(expression)
+	tokens, err := tokenize(expression)
 	return &Parser{
 		tokens: tokens,
 		pos:    0,
+		end:    len([]rune(expression)) + 1,
+		err:    err,
 	}
 }
 
 // Parse 解析表达式
 func (p *Parser) Parse() (Expression, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
 	if len(p.tokens) == 0 {
 		return nil, fmt.Errorf("表达式为空")
 	}
-	return p.parseExpression()
+
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	// 表达式解析完成后不能有剩余的符号
+	if p.peek().Type != TokenEOF {
+		return nil, p.unexpected("期望表达式结束")
+	}
+	return expr, nil
 }
 
 // parseExpression 解析表达式
 func (p *Parser) parseExpression() (Expression, error) {
-	return p.parseLogicalOr()
+	return p.parsePipe()
 }
 
-// parseLogicalOr 解析逻辑或表达式
-func (p *Parser) parseLogicalOr() (Expression, error) {
-	left, err := p.parseLogicalAnd()
+// parsePipe 解析过滤器管道，如 price | number、name | replace('a', 'b')；
+// 管道优先级最低，左侧的值作为过滤函数的第一个参数
+func (p *Parser) parsePipe() (Expression, error) {
+	expr, err := p.parseConditional()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(OpOr) {
-		operator := p.previous().Value
-		right, err := p.parseLogicalAnd()
-		if err != nil {
+	for p.match(OpPipe) {
+		if !p.matchType(TokenIdentifier) {
+			return nil, p.unexpected("期望过滤器名称")
+		}
+		name := p.previous()
+		if err := checkFunction(name); err != nil {
 			return nil, err
 		}
-		left = &BinaryExpression{Left: left, Operator: operator, Right: right}
+
+		filter := &CallExpression{Name: name.Value, Args: []Expression{expr}}
+		if p.matchType(TokenParenLeft) {
+			args, err := p.parseArguments()
+			if err != nil {
+				return nil, err
+			}
+			filter.Args = append(filter.Args, args...)
+		}
+		expr = filter
 	}
 
-	return left, nil
+	return expr, nil
+}
+
+// parseConditional 解析三元表达式，右结合：a ? b : c ? d : e 等价于 a ? b : (c ? d : e)
+func (p *Parser) parseConditional() (Expression, error) {
+	condition, err := p.parseCoalesce()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(OpTernary) {
+		return condition, nil
+	}
+
+	then, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if !p.matchType(TokenColon) {
+		return nil, p.unexpected("期望三元表达式的冒号")
+	}
+	otherwise, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	return &ConditionalExpression{Condition: condition, Then: then, Else: otherwise}, nil
+}
+
+// parseCoalesce 解析空值合并表达式，右结合
+func (p *Parser) parseCoalesce() (Expression, error) {
+	left, err := p.parseLogicalOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(OpCoalesce) {
+		return left, nil
+	}
+
+	right, err := p.parseCoalesce()
+	if err != nil {
+		return nil, err
+	}
+	return &CoalesceExpression{Left: left, Right: right}, nil
+}
+
+// parseLogicalOr 解析逻辑或表达式
+func (p *Parser) parseLogicalOr() (Expression, error) {
+	return p.parseBinary(p.parseLogicalAnd, OpOr)
 }
 
 // parseLogicalAnd 解析逻辑与表达式
 func (p *Parser) parseLogicalAnd() (Expression, error) {
-	left, err := p.parseComparison()
+	return p.parseBinary(p.parseEquality, OpAnd)
+}
+
+// parseEquality 解析相等比较表达式
+func (p *Parser) parseEquality() (Expression, error) {
+	return p.parseBinary(p.parseComparison, OpEQ, OpNE, OpMatch, OpNotMatch)
+}
+
+// parseComparison 解析大小比较表达式
+func (p *Parser) parseComparison() (Expression, error) {
+	return p.parseBinary(p.parseTerm, OpGT, OpGE, OpLT, OpLE, OpIn, OpNotIn)
+}
+
+// parseTerm 解析加减表达式
+func (p *Parser) parseTerm() (Expression, error) {
+	return p.parseBinary(p.parseFactor, OpAdd, OpSub)
+}
+
+// parseFactor 解析乘除和取模表达式
+func (p *Parser) parseFactor() (Expression, error) {
+	return p.parseBinary(p.parseUnary, OpMul, OpDiv, OpMod)
+}
+
+// parseBinary 解析同一优先级的左结合二元表达式，next解析更高优先级的操作数
+func (p *Parser) parseBinary(next func() (Expression, error), operators ...string) (Expression, error) {
+	left, err := next()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(OpAnd) {
+	for p.match(operators...) {
 		operator := p.previous().Value
-		right, err := p.parseComparison()
+		right, err := next()
 		if err != nil {
 			return nil, err
 		}
@@ -204,82 +511,217 @@ func (p *Parser) parseLogicalAnd() (Expression, error) {
 	return left, nil
 }
 
-// parseComparison 解析比较表达式
-func (p *Parser) parseComparison() (Expression, error) {
-	left, err := p.parseTerm()
+// parseUnary 解析一元表达式
+func (p *Parser) parseUnary() (Expression, error) {
+	if p.match(OpNot, OpNeg) {
+		operator := p.previous().Value
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpression{Operator: operator, Operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+// parsePrimary 解析主表达式及其后的成员访问和下标访问
+func (p *Parser) parsePrimary() (Expression, error) {
+	expr, err := p.parseOperand()
 	if err != nil {
 		return nil, err
 	}
 
-	operators := []string{OpEQ, OpNE, OpGT, OpGE, OpLT, OpLE}
-	for _, op := range operators {
-		if p.match(op) {
-			operator := p.previous().Value
-			right, err := p.parseTerm()
+	for {
+		switch {
+		case p.matchType(TokenDot):
+			if !p.matchType(TokenIdentifier) {
+				return nil, p.unexpected("期望字段名")
+			}
+			expr = &MemberExpression{Object: expr, Name: p.previous().Value}
+		case p.matchType(TokenBracketLeft):
+			index, err := p.parseExpression()
 			if err != nil {
 				return nil, err
 			}
-			left = &BinaryExpression{Left: left, Operator: operator, Right: right}
+			if !p.matchType(TokenBracketRight) {
+				return nil, p.unexpected("期望右方括号")
+			}
+			expr = &IndexExpression{Object: expr, Index: index}
+		default:
+			return expr, nil
 		}
 	}
-
-	return left, nil
 }
 
-// parseTerm 解析项
-func (p *Parser) parseTerm() (Expression, error) {
-	if p.match(OpNot) {
-		operator := p.previous().Value
-		operand, err := p.parseTerm()
-		if err != nil {
-			return nil, err
-		}
-		return &UnaryExpression{Operator: operator, Operand: operand}, nil
-	}
-
+// parseOperand 解析括号、字面量、变量和函数调用
+func (p *Parser) parseOperand() (Expression, error) {
 	if p.matchType(TokenParenLeft) {
 		expr, err := p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
 		if !p.matchType(TokenParenRight) {
-			return nil, fmt.Errorf("期望右括号")
+			return nil, p.unexpected("期望右括号")
 		}
 		return expr, nil
 	}
 
-	return p.parsePrimary()
-}
-
-// parsePrimary 解析主表达式
-func (p *Parser) parsePrimary() (Expression, error) {
 	if p.matchType(TokenNumber) {
-		val := p.previous().Value
-		num, err := strconv.ParseFloat(val, 64)
+		token := p.previous()
+		num, err := strconv.ParseFloat(token.Value, 64)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("第%d列: 无效的数字 '%s'", token.Pos, token.Value)
 		}
 		return &LiteralExpression{Value: num}, nil
 	}
 
 	if p.matchType(TokenString) {
 		val := p.previous().Value
-		return &LiteralExpression{Value: strings.Trim(val, "'\"")}, nil
+		return &LiteralExpression{Value: val[1 : len(val)-1]}, nil
 	}
 
 	if p.matchType(TokenIdentifier) {
-		name := p.previous().Value
-		return &VariableExpression{Name: name}, nil
+		token := p.previous()
+		if p.matchType(TokenParenLeft) {
+			return p.parseCall(token)
+		}
+		if value, isKeyword := literalKeywords[token.Value]; isKeyword {
+			return &LiteralExpression{Value: value}, nil
+		}
+		return &VariableExpression{Name: token.Value}, nil
+	}
+
+	if p.matchType(TokenBracketLeft) {
+		return p.parseList()
 	}
 
-	return nil, fmt.Errorf("期望表达式")
+	if p.matchType(TokenBraceLeft) {
+		return p.parseMap()
+	}
+
+	return nil, p.unexpected("期望表达式")
 }
 
-// match 匹配特定操作符
-func (p *Parser) match(operator string) bool {
-	if p.peek().Type == TokenOperator && p.peek().Value == operator {
-		p.advance()
-		return true
+// parseCall 解析函数调用，左括号已被匹配
+func (p *Parser) parseCall(name Token) (Expression, error) {
+	if err := checkFunction(name); err != nil {
+		return nil, err
+	}
+	args, err := p.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+	return &CallExpression{Name: name.Value, Args: args}, nil
+}
+
+// parseArguments 解析函数调用的参数列表，左括号已被匹配
+func (p *Parser) parseArguments() ([]Expression, error) {
+	var args []Expression
+	if p.matchType(TokenParenRight) {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if p.matchType(TokenParenRight) {
+			return args, nil
+		}
+		if !p.matchType(TokenComma) {
+			return nil, p.unexpected("期望逗号或右括号")
+		}
+	}
+}
+
+// checkFunction 检查函数是否已注册
+func checkFunction(name Token) error {
+	if _, exists := LookupFunction(name.Value); !exists {
+		return fmt.Errorf("第%d列: 未知的函数 '%s'", name.Pos, name.Value)
+	}
+	return nil
+}
+
+// parseList 解析列表字面量，左方括号已被匹配
+func (p *Parser) parseList() (Expression, error) {
+	list := &ListExpression{}
+	if p.matchType(TokenBracketRight) {
+		return list, nil
+	}
+	for {
+		item, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, item)
+
+		if p.matchType(TokenBracketRight) {
+			return list, nil
+		}
+		if !p.matchType(TokenComma) {
+			return nil, p.unexpected("期望逗号或右方括号")
+		}
+	}
+}
+
+// parseMap 解析映射字面量，左花括号已被匹配；键可以是标识符、字符串或数字
+func (p *Parser) parseMap() (Expression, error) {
+	fields := &MapExpression{}
+	if p.matchType(TokenBraceRight) {
+		return fields, nil
+	}
+	for {
+		var key string
+		switch {
+		case p.matchType(TokenIdentifier), p.matchType(TokenNumber):
+			key = p.previous().Value
+		case p.matchType(TokenString):
+			key = p.previous().Value
+			key = key[1 : len(key)-1]
+		default:
+			return nil, p.unexpected("期望映射的键")
+		}
+		if !p.matchType(TokenColon) {
+			return nil, p.unexpected("期望冒号")
+		}
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		fields.Keys = append(fields.Keys, key)
+		fields.Values = append(fields.Values, value)
+
+		if p.matchType(TokenBraceRight) {
+			return fields, nil
+		}
+		if !p.matchType(TokenComma) {
+			return nil, p.unexpected("期望逗号或右花括号")
+		}
+	}
+}
+
+// unexpected 生成当前位置的语法错误
+func (p *Parser) unexpected(expected string) error {
+	token := p.peek()
+	if token.Type == TokenEOF {
+		return fmt.Errorf("第%d列: %s，但表达式已结束", token.Pos, expected)
+	}
+	return fmt.Errorf("第%d列: %s，实际为 '%s'", token.Pos, expected, token.Value)
+}
+
+// match 匹配任一操作符
+func (p *Parser) match(operators ...string) bool {
+	if p.peek().Type != TokenOperator {
+		return false
+	}
+	for _, operator := range operators {
+		if p.peek().Value == operator {
+			p.advance()
+			return true
+		}
 	}
 	return false
 }
@@ -296,7 +738,7 @@ func (p *Parser) matchType(tokenType TokenType) bool {
 // peek 查看当前token
 func (p *Parser) peek() Token {
 	if p.pos >= len(p.tokens) {
-		return Token{Type: TokenEOF, Value: ""}
+		return Token{Type: TokenEOF, Value: "", Pos: p.end}
 	}
 	return p.tokens[p.pos]
 }
@@ -316,185 +758,602 @@ func (p *Parser) previous() Token {
 	return Token{Type: TokenEOF, Value: ""}
 }
 
-// tokenize 词法分析
-func tokenize(expression string) []Token {
+// punctuation 括号等标点符号
+const punctuation = "()[]{},:."
+
+// punctuationTokens 标点符号对应的词法单元类型
+var punctuationTokens = map[rune]TokenType{
+	'(': TokenParenLeft,
+	')': TokenParenRight,
+	'[': TokenBracketLeft,
+	']': TokenBracketRight,
+	'{': TokenBraceLeft,
+	'}': TokenBraceRight,
+	',': TokenComma,
+	':': TokenColon,
+	'.': TokenDot,
+}
+
+// isIdentifierStart 检查字符能否作为标识符的开头
+func isIdentifierStart(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+// isIdentifierPart 检查字符能否出现在标识符中
+func isIdentifierPart(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_'
+}
+
+// tokenize 词法分析，遇到无法识别的字符时返回带列号的错误
+func tokenize(expression string) ([]Token, error) {
 	var tokens []Token
-	var current strings.Builder
+	runes := []rune(expression)
 	i := 0
 
-	for i < len(expression) {
-		ch := rune(expression[i])
+	for i < len(runes) {
+		ch := runes[i]
+		start := i
 
+		switch {
 		// 跳过空白字符
-		if unicode.IsSpace(ch) {
+		case unicode.IsSpace(ch):
 			i++
-			continue
-		}
 
 		// 处理数字
-		if unicode.IsDigit(ch) {
-			current.Reset()
-			for i < len(expression) && (unicode.IsDigit(rune(expression[i])) || expression[i] == '.') {
-				current.WriteByte(expression[i])
+		case unicode.IsDigit(ch):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
 				i++
 			}
-			tokens = append(tokens, Token{Type: TokenNumber, Value: current.String()})
-			continue
-		}
+			tokens = append(tokens, Token{Type: TokenNumber, Value: string(runes[start:i]), Pos: start + 1})
 
 		// 处理字符串
-		if ch == '\'' || ch == '"' {
-			current.Reset()
-			quote := ch
+		case ch == '\'' || ch == '"':
 			i++
-			for i < len(expression) && rune(expression[i]) != quote {
-				current.WriteByte(expression[i])
+			for i < len(runes) && runes[i] != ch {
 				i++
 			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("第%d列: 字符串缺少结束引号 %c", start+1, ch)
+			}
 			i++
-			tokens = append(tokens, Token{Type: TokenString, Value: string(quote) + current.String() + string(quote)})
-			continue
-		}
+			tokens = append(tokens, Token{Type: TokenString, Value: string(runes[start:i]), Pos: start + 1})
 
 		// 处理标识符
-		if unicode.IsLetter(ch) || ch == '_' {
-			current.Reset()
-			for i < len(expression) && (unicode.IsLetter(rune(expression[i])) || unicode.IsDigit(rune(expression[i])) || expression[i] == '_') {
-				current.WriteByte(expression[i])
+		case isIdentifierStart(ch):
+			for i < len(runes) && isIdentifierPart(runes[i]) {
 				i++
 			}
-			tokens = append(tokens, Token{Type: TokenIdentifier, Value: current.String()})
-			continue
-		}
+			word := string(runes[start:i])
 
-		// 处理操作符
-		if strings.Contains("==!><=&&||", string(ch)) {
-			current.Reset()
-			current.WriteRune(ch)
-			
-			// 检查多字符操作符
-			if i+1 < len(expression) {
-				nextCh := rune(expression[i+1])
-				if strings.Contains("=!<>&|", string(nextCh)) && strings.Contains("==!><=&&||", string(ch)+string(nextCh)) {
-					current.WriteRune(nextCh)
-					i++
+			// in 和 not in 是关键字操作符
+			if word == OpIn {
+				tokens = append(tokens, Token{Type: TokenOperator, Value: OpIn, Pos: start + 1})
+				continue
+			}
+			if word == "not" {
+				next := i
+				for next < len(runes) && unicode.IsSpace(runes[next]) {
+					next++
+				}
+				if next > i && next+2 <= len(runes) && string(runes[next:next+2]) == OpIn && (next+2 == len(runes) || !isIdentifierPart(runes[next+2])) {
+					i = next + 2
+					tokens = append(tokens, Token{Type: TokenOperator, Value: OpNotIn, Pos: start + 1})
+					continue
 				}
 			}
+			tokens = append(tokens, Token{Type: TokenIdentifier, Value: word, Pos: start + 1})
+
+		// 处理括号和标点
+		case strings.ContainsRune(punctuation, ch):
+			tokens = append(tokens, Token{Type: punctuationTokens[ch], Value: string(ch), Pos: start + 1})
 			i++
-			tokens = append(tokens, Token{Type: TokenOperator, Value: current.String()})
-			continue
+
+		// 处理操作符
+		default:
+			operator := matchOperator(runes[i:])
+			if operator == "" {
+				if ch == '=' {
+					return nil, fmt.Errorf("第%d列: 无法识别的字符 '='，判断相等请使用 '=='", start+1)
+				}
+				return nil, fmt.Errorf("第%d列: 无法识别的字符 '%c'", start+1, ch)
+			}
+			tokens = append(tokens, Token{Type: TokenOperator, Value: operator, Pos: start + 1})
+			i += len([]rune(operator))
 		}
+	}
 
-		// 处理括号
-		if ch == '(' {
-			tokens = append(tokens, Token{Type: TokenParenLeft, Value: "("})
-			i++
-			continue
+	return tokens, nil
+}
+
+// matchOperator 匹配输入开头的操作符，未匹配时返回空字符串
+func matchOperator(input []rune) string {
+	for _, operator := range operators {
+		if strings.HasPrefix(string(input), operator) {
+			return operator
 		}
-		if ch == ')' {
-			tokens = append(tokens, Token{Type: TokenParenRight, Value: ")"})
-			i++
+	}
+	return ""
+}
+
+// 比较函数
+
+// valuesEqual 相等比较：空值只与空值相等；两边都能解析为数值时按数值比较；
+// 有一边是布尔值时，另一边必须是布尔值或 "true"/"false" 字符串；列表和映射逐个元素比较；其余按字符串比较。
+// 严格模式下两边类型必须相同（空值除外），字符串不会被当作数值
+func valuesEqual(a, b interface{}, strict bool) (bool, error) {
+	kindA, kindB := valueKind(a), valueKind(b)
+	if kindA == kindNull || kindB == kindNull {
+		return kindA == kindB, nil
+	}
+	if strict && kindA != kindB {
+		return false, typeMismatch(OpEQ, a, b)
+	}
+
+	if !strict || kindA == kindNumber {
+		if numA, ok := toNumber(a); ok {
+			if numB, ok := toNumber(b); ok {
+				return numA == numB, nil
+			}
+		}
+	}
+
+	switch {
+	case kindA == kindBool || kindB == kindBool:
+		boolA, okA := parseBool(a)
+		boolB, okB := parseBool(b)
+		return okA && okB && boolA == boolB, nil
+	case kindA == kindList && kindB == kindList:
+		listA, _ := toList(a)
+		listB, _ := toList(b)
+		if len(listA) != len(listB) {
+			return false, nil
+		}
+		for i := range listA {
+			if eq, err := valuesEqual(listA[i], listB[i], strict); err != nil || !eq {
+				return false, err
+			}
+		}
+		return true, nil
+	case kindA == kindMap && kindB == kindMap:
+		mapA, okA := a.(map[string]interface{})
+		mapB, okB := b.(map[string]interface{})
+		if !okA || !okB {
+			return reflect.DeepEqual(a, b), nil
+		}
+		if len(mapA) != len(mapB) {
+			return false, nil
+		}
+		for key, valueA := range mapA {
+			valueB, exists := mapB[key]
+			if !exists {
+				return false, nil
+			}
+			if eq, err := valuesEqual(valueA, valueB, strict); err != nil || !eq {
+				return false, err
+			}
+		}
+		return true, nil
+	case kindA == kindTime || kindB == kindTime:
+		timeA, timeB, err := timeOperands(OpEQ, a, b)
+		if err != nil {
+			return false, nil
+		}
+		return timeA.Equal(timeB), nil
+	}
+
+	return formatVariable(a) == formatVariable(b), nil
+}
+
+// compareValues 大小比较，返回负数、0或正数：两边都能解析为数值时按数值比较，时间按先后比较（另一边为日期字符串时先转换为时间），
+// 其余的字符串和数值按字典序比较；空值、布尔值、列表和映射不能比较大小，此时ok为false。
+// 严格模式下两边类型必须相同，不能比较时返回错误
+func compareValues(operator string, a, b interface{}, strict bool) (result int, ok bool, err error) {
+	kindA, kindB := valueKind(a), valueKind(b)
+	if strict && kindA != kindB {
+		return 0, false, typeMismatch(operator, a, b)
+	}
+
+	if !strict || kindA == kindNumber {
+		if numA, okA := toNumber(a); okA {
+			if numB, okB := toNumber(b); okB {
+				return cmp.Compare(numA, numB), true, nil
+			}
+		}
+	}
+
+	// 时间只能与时间或日期字符串比较，与空值以外的其他类型比较时报错
+	if (kindA == kindTime || kindB == kindTime) && kindA != kindNull && kindB != kindNull {
+		timeA, timeB, err := timeOperands(operator, a, b)
+		if err != nil {
+			return 0, false, err
+		}
+		return timeA.Compare(timeB), true, nil
+	}
+
+	if isScalarKind(kindA) && isScalarKind(kindB) {
+		return strings.Compare(formatVariable(a), formatVariable(b)), true, nil
+	}
+
+	if strict {
+		return 0, false, fmt.Errorf("%s不能进行 %s 比较: %s", kindName(a), operator, describeValue(a))
+	}
+	return 0, false, nil
+}
+
+// timeOperands 将时间比较的两个操作数转换为时间，其中一边为时间，另一边必须为时间或日期字符串
+func timeOperands(operator string, a, b interface{}) (time.Time, time.Time, error) {
+	operands := [2]time.Time{}
+	for i, value := range []interface{}{a, b} {
+		if t, ok := value.(time.Time); ok {
+			operands[i] = t
 			continue
 		}
+		text, ok := value.(string)
+		if !ok {
+			return time.Time{}, time.Time{}, typeMismatch(operator, a, b)
+		}
+		// 数字字符串不按时间戳解析，避免 '2025' 被当作1970年的时间而静默比较
+		t, err := parseDate(text)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("时间不能与 %s 进行 %s 比较: %w", describeValue(text), operator, err)
+		}
+		operands[i] = t
+	}
+	return operands[0], operands[1], nil
+}
+
+// compare 执行大小比较操作符，非严格模式下不能比较的值结果为false
+func compare(operator string, a, b interface{}, strict bool) (bool, error) {
+	result, ok, err := compareValues(operator, a, b, strict)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	switch operator {
+	case OpGT:
+		return result > 0, nil
+	case OpGE:
+		return result >= 0, nil
+	case OpLT:
+		return result < 0, nil
+	case OpLE:
+		return result <= 0, nil
+	default:
+		return false, fmt.Errorf("不支持的比较操作符: %s", operator)
+	}
+}
+
+// 成员与下标访问函数
+
+// describeExpression 生成变量路径的描述，用于错误信息
+func describeExpression(expr Expression) string {
+	switch e := expr.(type) {
+	case *VariableExpression:
+		return e.Name
+	case *MemberExpression:
+		return describeExpression(e.Object) + "." + e.Name
+	case *IndexExpression:
+		return describeExpression(e.Object) + "[" + describeExpression(e.Index) + "]"
+	case *LiteralExpression:
+		if text, ok := e.Value.(string); ok {
+			return "'" + text + "'"
+		}
+		return formatVariable(e.Value)
+	default:
+		return "(...)"
+	}
+}
+
+// memberOf 获取映射中的字段，字段不存在或所属的值为null时返回ErrUndefinedVariable，可被 ?? 取默认值
+func memberOf(object interface{}, key string, path string) (interface{}, error) {
+	if object == nil {
+		return nil, fmt.Errorf("%w: %s（所属的值为null）", ErrUndefinedVariable, path)
+	}
+
+	if fields, ok := object.(map[string]interface{}); ok {
+		if val, exists := fields[key]; exists {
+			return val, nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUndefinedVariable, path)
+	}
 
-		i++
+	rv := reflect.ValueOf(object)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		val := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+		if !val.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrUndefinedVariable, path)
+		}
+		return val.Interface(), nil
 	}
 
-	return tokens
+	return nil, fmt.Errorf("%s: 值 %v 不是映射，无法访问字段 %s", path, object, key)
 }
 
-// 比较函数
-func equal(a, b interface{}) bool {
-	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+// indexOf 按下标访问列表或字符串，负数下标从末尾开始计数；对映射按键访问
+func indexOf(object interface{}, index interface{}, path string) (interface{}, error) {
+	list, isList := toList(object)
+	text, isText := object.(string)
+	if !isList && !isText {
+		return memberOf(object, formatVariable(index), path)
+	}
+
+	length := len(list)
+	var runes []rune
+	if isText {
+		runes = []rune(text)
+		length = len(runes)
+	}
+
+	num, ok := toNumber(index)
+	if !ok || num != math.Trunc(num) {
+		return nil, fmt.Errorf("%s: 下标必须是整数: %v", path, index)
+	}
+	i := int(num)
+	if i < 0 {
+		i += length
+	}
+	if i < 0 || i >= length {
+		return nil, fmt.Errorf("%s: 下标越界，长度为%d", path, length)
+	}
+
+	if isText {
+		return string(runes[i]), nil
+	}
+	return list[i], nil
 }
 
-func greaterThan(a, b interface{}) bool {
-	numA, okA := toNumber(a)
-	numB, okB := toNumber(b)
-	if okA && okB {
-		return numA > numB
+// containsValue in操作：列表是否包含元素、映射是否包含键、字符串是否包含子串；
+// 列表元素按相等比较的规则判断，严格模式下类型不同的元素视为不相等
+func containsValue(container, item interface{}, strict bool) (bool, error) {
+	if list, ok := toList(container); ok {
+		for _, element := range list {
+			if strict && valueKind(element) != valueKind(item) {
+				continue
+			}
+			if eq, err := valuesEqual(element, item, strict); err == nil && eq {
+				return true, nil
+			}
+		}
+		return false, nil
 	}
-	return false
+
+	if text, ok := container.(string); ok {
+		return strings.Contains(text, formatVariable(item)), nil
+	}
+
+	rv := reflect.ValueOf(container)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		key := reflect.ValueOf(formatVariable(item)).Convert(rv.Type().Key())
+		return rv.MapIndex(key).IsValid(), nil
+	}
+
+	return false, fmt.Errorf("in的右侧必须是列表、映射或字符串: %v", container)
 }
 
-func greaterThanOrEqual(a, b interface{}) bool {
-	numA, okA := toNumber(a)
-	numB, okB := toNumber(b)
-	if okA && okB {
-		return numA >= numB
+// regexMatch =~操作：左侧文本是否匹配右侧的正则表达式，空值不匹配任何正则；严格模式下两侧都必须是字符串
+func regexMatch(text, pattern interface{}, strict bool) (bool, error) {
+	if strict && (valueKind(text) != kindString || valueKind(pattern) != kindString) {
+		return false, typeMismatch(OpMatch, text, pattern)
 	}
-	return false
+	re, err := compileRegex(formatVariable(pattern))
+	if err != nil {
+		return false, err
+	}
+	if text == nil {
+		return false, nil
+	}
+	return re.MatchString(formatVariable(text)), nil
 }
 
-func lessThan(a, b interface{}) bool {
+// 算术函数
+
+// add 加法：两个操作数都是数值时相加，否则按字符串拼接；
+// 严格模式下只允许数值相加或字符串拼接
+func add(a, b interface{}, strict bool) (interface{}, error) {
+	if strict {
+		kindA, kindB := valueKind(a), valueKind(b)
+		switch {
+		case kindA == kindNumber && kindB == kindNumber:
+			numA, _ := toNumber(a)
+			numB, _ := toNumber(b)
+			return numA + numB, nil
+		case kindA == kindString && kindB == kindString:
+			return a.(string) + b.(string), nil
+		default:
+			return nil, typeMismatch(OpAdd, a, b)
+		}
+	}
+
 	numA, okA := toNumber(a)
 	numB, okB := toNumber(b)
 	if okA && okB {
-		return numA < numB
+		return numA + numB, nil
 	}
-	return false
+	return formatVariable(a) + formatVariable(b), nil
 }
 
-func lessThanOrEqual(a, b interface{}) bool {
+// arithmetic 减、乘、除、取模运算，两个操作数都必须是数值；严格模式下不接受数字字符串
+func arithmetic(operator string, a, b interface{}, strict bool) (interface{}, error) {
+	if strict && (valueKind(a) != kindNumber || valueKind(b) != kindNumber) {
+		return nil, typeMismatch(operator, a, b)
+	}
+
 	numA, okA := toNumber(a)
 	numB, okB := toNumber(b)
-	if okA && okB {
-		return numA <= numB
+	if !okA || !okB {
+		return nil, fmt.Errorf("无法对 %v 和 %v 执行 %s 运算，操作数必须是数值", a, b, operator)
+	}
+
+	switch operator {
+	case OpSub:
+		return numA - numB, nil
+	case OpMul:
+		return numA * numB, nil
+	case OpDiv:
+		if numB == 0 {
+			return nil, fmt.Errorf("除数不能为0")
+		}
+		return numA / numB, nil
+	case OpMod:
+		if numB == 0 {
+			return nil, fmt.Errorf("取模的除数不能为0")
+		}
+		return math.Mod(numA, numB), nil
+	default:
+		return nil, fmt.Errorf("不支持的算术操作符: %s", operator)
 	}
-	return false
 }
 
 // 逻辑函数
-func logicalAnd(a, b interface{}) bool {
-	boolA := toBoolean(a)
-	boolB := toBoolean(b)
-	return boolA && boolB
+
+// truthy 判断值的真假：空值、false、0、空字符串、"false"字符串、空列表和空映射为假，其余为真；
+// 严格模式下操作数必须是布尔值
+func truthy(operator string, value interface{}, strict bool) (bool, error) {
+	if strict {
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+		return false, fmt.Errorf("严格模式下 %s 的操作数必须是布尔值，实际为%s %s", operator, kindName(value), describeValue(value))
+	}
+
+	switch valueKind(value) {
+	case kindNull:
+		return false, nil
+	case kindBool:
+		return value.(bool), nil
+	case kindNumber:
+		num, _ := toNumber(value)
+		return num != 0, nil
+	case kindString:
+		text := strings.TrimSpace(value.(string))
+		return text != "" && !strings.EqualFold(text, "false"), nil
+	case kindList, kindMap:
+		return reflect.ValueOf(value).Len() > 0, nil
+	default:
+		return true, nil
+	}
 }
 
-func logicalOr(a, b interface{}) bool {
-	boolA := toBoolean(a)
-	boolB := toBoolean(b)
-	return boolA || boolB
+// 类型转换函数
+
+// 值的类型，用于比较和严格模式下的类型检查
+const (
+	kindNull   = "null"
+	kindBool   = "bool"
+	kindNumber = "number"
+	kindString = "string"
+	kindList   = "list"
+	kindMap    = "map"
+	kindTime   = "time"
+	kindOther  = "other"
+)
+
+// kindNames 类型在错误信息中的名称
+var kindNames = map[string]string{
+	kindNull:   "空值",
+	kindBool:   "布尔值",
+	kindNumber: "数值",
+	kindString: "字符串",
+	kindList:   "列表",
+	kindMap:    "映射",
+	kindTime:   "时间",
+	kindOther:  "未知类型",
 }
 
-func logicalNot(a interface{}) bool {
-	return !toBoolean(a)
+// valueKind 获取值的类型
+func valueKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return kindNull
+	case bool:
+		return kindBool
+	case string:
+		return kindString
+	case time.Time:
+		return kindTime
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return kindNumber
+	case reflect.Slice, reflect.Array:
+		return kindList
+	case reflect.Map:
+		return kindMap
+	default:
+		return kindOther
+	}
 }
 
-// 类型转换函数
+// kindName 获取值的类型名称
+func kindName(value interface{}) string {
+	return kindNames[valueKind(value)]
+}
+
+// isScalarKind 检查类型是否为可按字典序比较的字符串或数值
+func isScalarKind(kind string) bool {
+	return kind == kindString || kind == kindNumber
+}
+
+// describeValue 生成值的描述，字符串加引号以便和数值区分
+func describeValue(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return "'" + text + "'"
+	}
+	return formatVariable(value)
+}
+
+// typeMismatch 生成严格模式下的类型不匹配错误
+func typeMismatch(operator string, a, b interface{}) error {
+	return fmt.Errorf("类型不匹配: %s %s 与%s %s 不能进行 %s 运算", kindName(a), describeValue(a), kindName(b), describeValue(b), operator)
+}
+
+// toNumber 转换为数值，支持各种整数和浮点类型以及数字字符串
 func toNumber(value interface{}) (float64, bool) {
 	switch v := value.(type) {
-	case int:
-		return float64(v), true
 	case float64:
 		return v, true
+	case int:
+		return float64(v), true
 	case string:
-		num, err := strconv.ParseFloat(v, 64)
-		return num, err == nil
+		num, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return num, err == nil && !math.IsInf(num, 0) && !math.IsNaN(num)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
 	default:
 		return 0, false
 	}
 }
 
-func toBoolean(value interface{}) bool {
+// parseBool 转换为布尔值，只接受布尔值和 "true"/"false" 字符串（不区分大小写）
+func parseBool(value interface{}) (bool, bool) {
 	switch v := value.(type) {
 	case bool:
-		return v
-	case int:
-		return v != 0
-	case float64:
-		return v != 0
+		return v, true
 	case string:
-		return len(v) > 0
-	default:
-		return false
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
 	}
+	return false, false
 }
 
-// EvaluateExpression 评估表达式
+// EvaluateExpression 评估表达式，相同表达式的语法树只解析一次
 func EvaluateExpression(expr string, ctx *ExecutionContext) (interface{}, error) {
-	parser := NewParser(expr)
-	expression, err := parser.Parse()
+	expression, err := CompileExpression(expr)
 	if err != nil {
 		return nil, err
 	}
@@ -507,5 +1366,8 @@ func EvaluateBoolean(expr string, ctx *ExecutionContext) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return toBoolean(result), nil
-}
\ No newline at end of file
+	if ctx.Strict && valueKind(result) != kindBool {
+		return false, fmt.Errorf("严格模式下条件的结果必须是布尔值，实际为%s %s", kindName(result), describeValue(result))
+	}
+	return truthy("", result, false)
+}
@@ -0,0 +1,194 @@
+package operator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestContext 创建包含测试变量的执行上下文
+func newTestContext(strict bool) *ExecutionContext {
+	ctx := NewExecutionContext()
+	ctx.Strict = strict
+	ctx.SetVariable("count", 3)
+	ctx.SetVariable("price", "4799")
+	ctx.SetVariable("name", "iPhone")
+	ctx.SetVariable("enabled", true)
+	ctx.SetVariable("flag", "false")
+	ctx.SetVariable("empty", "")
+	ctx.SetVariable("nothing", nil)
+	ctx.SetVariable("tags", []interface{}{"手机", "苹果"})
+	ctx.SetVariable("product", map[string]interface{}{"name": "iPhone", "price": 4799.0})
+	ctx.SetVariable("orderTime", time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local))
+	return ctx
+}
+
+func TestEvaluateExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		strict  bool
+		want    interface{}
+		wantErr string // 期望错误信息中包含的内容，为空表示不期望出错
+	}{
+		// 字面量
+		{name: "布尔字面量", expr: "true", want: true},
+		{name: "空值字面量", expr: "null", want: nil},
+		{name: "字符串字面量", expr: "'abc'", want: "abc"},
+
+		// 优先级
+		{name: "乘法优先于加法", expr: "1 + 2 * 3", want: 7.0},
+		{name: "括号改变优先级", expr: "(1 + 2) * 3", want: 9.0},
+		{name: "比较优先于逻辑与", expr: "count > 2 && count < 5", want: true},
+		{name: "逻辑与优先于逻辑或", expr: "true || false && false", want: true},
+		{name: "一元负号", expr: "-count + 1", want: -2.0},
+
+		// 相等比较
+		{name: "数值与数字字符串相等", expr: "1 == '1.0'", want: true},
+		{name: "数字字符串按数值比较", expr: "price == 4799", want: true},
+		{name: "字符串相等", expr: "name == 'iPhone'", want: true},
+		{name: "字符串区分大小写", expr: "name == 'iphone'", want: false},
+		{name: "布尔值与字符串true相等", expr: "enabled == 'true'", want: true},
+		{name: "布尔值与数值不相等", expr: "enabled == 1", want: false},
+		{name: "空值与空值相等", expr: "nothing == null", want: true},
+		{name: "空值与空字符串不相等", expr: "nothing == ''", want: false},
+		{name: "空值与0不相等", expr: "null != 0", want: true},
+		{name: "列表逐个元素比较", expr: "[1, '2'] == ['1', 2]", want: true},
+		{name: "映射逐个字段比较", expr: "product == {name: 'iPhone', price: 4799}", want: true},
+
+		// 大小比较
+		{name: "数字字符串按数值比较大小", expr: "'10' > '9'", want: true},
+		{name: "非数字字符串按字典序比较", expr: "'apple' < 'banana'", want: true},
+		{name: "数字与非数字字符串按字典序比较", expr: "10 < 'a'", want: true},
+		{name: "空值不能比较大小", expr: "nothing > 0", want: false},
+		{name: "布尔值不能比较大小", expr: "enabled >= false", want: false},
+		{name: "时间与日期字符串比较", expr: "orderTime > '2024-01-01'", want: true},
+		{name: "日期字符串在左侧", expr: "'2024-03-15 10:30:00' >= orderTime", want: true},
+		{name: "当前时间晚于过去的日期", expr: "now() > '2020-01-01'", want: true},
+		{name: "时间与日期字符串相等", expr: "orderTime == '2024-03-15 10:30'", want: true},
+		{name: "时间与无法解析的字符串比较", expr: "orderTime < 'tomorrow'", wantErr: "无法解析日期"},
+		{name: "时间不与年份字符串比较", expr: "orderTime > '2025'", wantErr: "无法解析日期: 2025"},
+		{name: "时间不与数字日期字符串比较", expr: "orderTime < '20250101'", wantErr: "无法解析日期: 20250101"},
+		{name: "时间与无法解析的字符串不相等", expr: "orderTime != 'tomorrow'", want: true},
+		{name: "时间与数值不能比较大小", expr: "orderTime > 5", wantErr: "类型不匹配"},
+
+		// 逻辑运算与真假判断
+		{name: "字符串false为假", expr: "!flag", want: true},
+		{name: "空字符串为假", expr: "empty || false", want: false},
+		{name: "非空列表为真", expr: "tags && true", want: true},
+		{name: "短路求值不计算右侧", expr: "false && missing > 1", want: false},
+		{name: "逻辑或短路", expr: "true || missing", want: true},
+
+		// 算术与成员
+		{name: "减法左结合", expr: "10 - 2 - 3", want: 5.0},
+		{name: "除法左结合", expr: "12 / 3 / 2", want: 2.0},
+		{name: "取模", expr: "count % 2", want: 1.0},
+		{name: "乘法与一元负号", expr: "2 * -count", want: -6.0},
+		{name: "字符串不能做减法", expr: "name - 1", wantErr: "操作数必须是数值"},
+		{name: "数字字符串相加", expr: "price + 1", want: 4800.0},
+		{name: "字符串拼接", expr: "name + ' ' + count", want: "iPhone 3"},
+		{name: "in列表按数值比较", expr: "'1' in [1, 2]", want: true},
+		{name: "not in", expr: "'华为' not in tags", want: true},
+
+		// 正则匹配
+		{name: "正则匹配", expr: "name =~ '^iP'", want: true},
+		{name: "正则不匹配", expr: "name !~ '^\\d+$'", want: true},
+		{name: "正则匹配优先级低于加法", expr: "name + count =~ 'e3$'", want: true},
+		{name: "空值不匹配正则", expr: "nothing =~ '.*'", want: false},
+		{name: "match返回捕获组", expr: "match('订单号: AB12345', '([A-Z]+)(\\d+)')", want: []interface{}{"AB", "12345"}},
+		{name: "match无捕获组返回整个匹配", expr: "match('价格 4799 元', '\\d+')[0]", want: "4799"},
+		{name: "match未匹配返回空列表", expr: "len(match(name, '\\d'))", want: 0.0},
+		{name: "无效的正则表达式", expr: "name =~ '('", wantErr: "无效的正则表达式"},
+
+		// 三元与空值合并
+		{name: "三元表达式", expr: "count > 2 ? '多' : '少'", want: "多"},
+		{name: "三元表达式右结合", expr: "count > 5 ? 'a' : count > 2 ? 'b' : 'c'", want: "b"},
+		{name: "三元表达式只求值选中的分支", expr: "enabled ? name : missing", want: "iPhone"},
+		{name: "三元表达式优先级低于逻辑或", expr: "false || enabled ? 1 : 2", want: 1.0},
+		{name: "未定义变量取默认值", expr: "searchTerm ?? 'laptop'", want: "laptop"},
+		{name: "空值取默认值", expr: "nothing ?? 'laptop'", want: "laptop"},
+		{name: "空字符串不取默认值", expr: "empty ?? 'laptop'", want: ""},
+		{name: "缺少的字段取默认值", expr: "product.color ?? '黑色'", want: "黑色"},
+		{name: "空值的字段取默认值", expr: "nothing.name ?? '默认'", want: "默认"},
+		{name: "空值的下标取默认值", expr: "nothing[0] ?? '默认'", want: "默认"},
+		{name: "多层字段中间为空值时取默认值", expr: "{shop: null}.shop.owner.name ?? '未知'", want: "未知"},
+		{name: "访问空值的字段", expr: "nothing.name", wantErr: "变量未定义: nothing.name"},
+		{name: "空值合并链", expr: "missing ?? nothing ?? name", want: "iPhone"},
+		{name: "空值合并与管道", expr: "missing ?? 'abc' | upper", want: "ABC"},
+		{name: "映射中的三元表达式", expr: "{level: count > 2 ? 'high' : 'low'}.level", want: "high"},
+		{name: "三元表达式缺少冒号", expr: "enabled ? 1", wantErr: "期望三元表达式的冒号"},
+
+		// 严格模式
+		{name: "严格模式数值相等", expr: "count == 3", strict: true, want: true},
+		{name: "严格模式字符串不转换为数值", expr: "'1' == '1.0'", strict: true, want: false},
+		{name: "严格模式空值比较", expr: "nothing == null", strict: true, want: true},
+		{name: "严格模式相等类型不匹配", expr: "1 == '1'", strict: true, wantErr: "类型不匹配"},
+		{name: "严格模式大小比较类型不匹配", expr: "price > 4000", strict: true, wantErr: "类型不匹配"},
+		{name: "严格模式字符串按字典序比较", expr: "'10' < '9'", strict: true, want: true},
+		{name: "严格模式不能比较布尔值大小", expr: "enabled > false", strict: true, wantErr: "不能进行 > 比较"},
+		{name: "严格模式加法类型不匹配", expr: "name + 1", strict: true, wantErr: "类型不匹配"},
+		{name: "严格模式算术不接受数字字符串", expr: "price * 2", strict: true, wantErr: "类型不匹配"},
+		{name: "严格模式逻辑运算要求布尔值", expr: "count && true", strict: true, wantErr: "必须是布尔值"},
+		{name: "严格模式取反要求布尔值", expr: "!empty", strict: true, wantErr: "必须是布尔值"},
+		{name: "严格模式in跳过不同类型", expr: "'1' in [1, 2]", strict: true, want: false},
+		{name: "严格模式时间不与字符串比较", expr: "orderTime > '2024-01-01'", strict: true, wantErr: "类型不匹配"},
+		{name: "严格模式正则要求字符串", expr: "count =~ '3'", strict: true, wantErr: "类型不匹配"},
+		{name: "严格模式三元条件要求布尔值", expr: "count ? 1 : 2", strict: true, wantErr: "必须是布尔值"},
+
+		// 错误
+		{name: "未定义的变量", expr: "missing == 1", wantErr: "变量未定义"},
+		{name: "除数为0", expr: "count / 0", wantErr: "除数不能为0"},
+		{name: "无法识别的字符带列号", expr: "count = 1", wantErr: "第7列"},
+		{name: "缺少右括号", expr: "(1 + 2", wantErr: "期望右括号"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateExpression(tt.expr, newTestContext(tt.strict))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("EvaluateExpression(%q) 错误 = %v，期望包含 %q", tt.expr, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvaluateExpression(%q) 返回错误: %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvaluateExpression(%q) = %#v，期望 %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateBoolean(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		strict  bool
+		want    bool
+		wantErr bool
+	}{
+		{name: "非零数值为真", expr: "count", want: true},
+		{name: "0为假", expr: "count - 3", want: false},
+		{name: "空值为假", expr: "nothing", want: false},
+		{name: "字符串false为假", expr: "flag", want: false},
+		{name: "非空字符串为真", expr: "name", want: true},
+		{name: "空映射为假", expr: "{}", want: false},
+		{name: "严格模式布尔结果", expr: "enabled", strict: true, want: true},
+		{name: "严格模式非布尔结果", expr: "count", strict: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateBoolean(tt.expr, newTestContext(tt.strict))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateBoolean(%q) 错误 = %v，期望出错: %v", tt.expr, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateBoolean(%q) = %v，期望 %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
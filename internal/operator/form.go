@@ -0,0 +1,95 @@
+package operator
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// select操作匹配选项的方式，对应by参数
+const (
+	SelectByLabel = "label" // 按选项显示的文本，默认
+	SelectByValue = "value" // 按选项的value属性
+	SelectByIndex = "index" // 按选项的下标，从0开始
+)
+
+// parseOptionIndexes 解析选项下标
+func parseOptionIndexes(options []string) ([]int, error) {
+	indexes := make([]int, 0, len(options))
+	for _, option := range options {
+		index, err := strconv.Atoi(strings.TrimSpace(option))
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("选项下标必须是非负整数: %s", option)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// validateForm 验证表单操作的参数
+func validateForm(action *Action) error {
+	if action.Type != ActionSelect {
+		if action.By != "" || len(action.Values) > 0 {
+			return fmt.Errorf("%s操作不支持by和values参数", action.Type)
+		}
+		return nil
+	}
+
+	switch action.By {
+	case "", SelectByLabel, SelectByValue, SelectByIndex:
+	default:
+		return fmt.Errorf("无效的by: %s，可选值为 label、value、index", action.By)
+	}
+	if action.Value != "" && len(action.Values) > 0 {
+		return fmt.Errorf("select操作不能同时设置value和values")
+	}
+	if action.Value == "" && len(action.Values) == 0 {
+		return fmt.Errorf("select操作需要提供value或values参数")
+	}
+
+	// 下标不含模板时在加载时检查
+	if action.By == SelectByIndex {
+		for _, option := range append([]string{action.Value}, action.Values...) {
+			if option == "" || strings.Contains(option, templateOpen) {
+				continue
+			}
+			if _, err := parseOptionIndexes([]string{option}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectOptions 执行select操作，value为单个选项，values为多选的选项列表；选项支持{{var}}模板
+func (ce *ControlExecutor) selectOptions(action *Action, selector, value string) error {
+	options := []string{value}
+	if len(action.Values) > 0 {
+		options = make([]string, 0, len(action.Values))
+		for _, option := range action.Values {
+			rendered, err := ce.replaceVariables(option)
+			if err != nil {
+				return fmt.Errorf("select操作的values模板替换失败: %w", err)
+			}
+			options = append(options, rendered)
+		}
+	} else if value == "" {
+		return fmt.Errorf("select操作需要提供value参数")
+	}
+
+	selected, err := ce.TaskManager.BrowserManager.SelectOptions(selector, action.By, options)
+	if err != nil {
+		return err
+	}
+
+	if action.OutputKey != "" {
+		values := make([]interface{}, 0, len(selected))
+		for _, option := range selected {
+			values = append(values, option)
+		}
+		ce.Context.SetVariable(action.OutputKey, values)
+		log.Printf("📋 选中的选项已存储到变量: %s", action.OutputKey)
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+package operator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Function 表达式中可调用的函数，参数为已求值的实参
+type Function func(args []interface{}) (interface{}, error)
+
+var (
+	functionMu       sync.RWMutex
+	functionRegistry = make(map[string]Function)
+)
+
+// RegisterFunction 注册表达式函数，同名函数会被替换，嵌入方可以借此扩展函数库
+func RegisterFunction(name string, fn Function) {
+	functionMu.Lock()
+	defer functionMu.Unlock()
+	functionRegistry[name] = fn
+}
+
+// LookupFunction 查找已注册的表达式函数
+func LookupFunction(name string) (Function, bool) {
+	functionMu.RLock()
+	defer functionMu.RUnlock()
+	fn, exists := functionRegistry[name]
+	return fn, exists
+}
+
+func init() {
+	RegisterFunction("len", withArgs(1, 1, fnLen))
+	RegisterFunction("contains", withArgs(2, 2, fnContains))
+	RegisterFunction("startsWith", withArgs(2, 2, stringPredicate(strings.HasPrefix)))
+	RegisterFunction("endsWith", withArgs(2, 2, stringPredicate(strings.HasSuffix)))
+	RegisterFunction("lower", withArgs(1, 1, stringFunction(strings.ToLower)))
+	RegisterFunction("upper", withArgs(1, 1, stringFunction(strings.ToUpper)))
+	RegisterFunction("trim", withArgs(1, 2, fnTrim))
+	RegisterFunction("replace", withArgs(3, 3, fnReplace))
+	RegisterFunction("split", withArgs(2, 2, fnSplit))
+	RegisterFunction("join", withArgs(1, 2, fnJoin))
+	RegisterFunction("int", withArgs(1, 1, fnInt))
+	RegisterFunction("float", withArgs(1, 1, fnFloat))
+	RegisterFunction("number", withArgs(1, 1, fnFloat))
+	RegisterFunction("str", withArgs(1, 1, fnStr))
+	RegisterFunction("matches", withArgs(2, 2, fnMatches))
+	RegisterFunction("match", withArgs(2, 2, fnMatch))
+	RegisterFunction("now", withArgs(0, 0, fnNow))
+	RegisterFunction("formatDate", withArgs(1, 2, fnFormatDate))
+	RegisterFunction("min", withArgs(1, -1, extremum(OpLT)))
+	RegisterFunction("max", withArgs(1, -1, extremum(OpGT)))
+	RegisterFunction("round", withArgs(1, 2, fnRound))
+	RegisterFunction(FunctionDefault, withArgs(2, 2, fnDefault))
+	RegisterFunction("uuid", withArgs(0, 0, fnUUID))
+}
+
+// FunctionDefault default函数名，其第一个参数引用未定义的变量时视为空值而不报错
+const FunctionDefault = "default"
+
+// withArgs 为函数添加参数个数检查，max为-1表示不限个数
+func withArgs(min, max int, fn Function) Function {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			var expected string
+			switch {
+			case min == max:
+				expected = strconv.Itoa(min)
+			case max < 0:
+				expected = fmt.Sprintf("至少%d", min)
+			default:
+				expected = fmt.Sprintf("%d到%d", min, max)
+			}
+			return nil, fmt.Errorf("需要%s个参数，实际为%d个", expected, len(args))
+		}
+		return fn(args)
+	}
+}
+
+// stringFunction 将字符串转换函数包装为表达式函数
+func stringFunction(convert func(string) string) Function {
+	return func(args []interface{}) (interface{}, error) {
+		return convert(formatVariable(args[0])), nil
+	}
+}
+
+// stringPredicate 将字符串判断函数包装为表达式函数
+func stringPredicate(predicate func(string, string) bool) Function {
+	return func(args []interface{}) (interface{}, error) {
+		return predicate(formatVariable(args[0]), formatVariable(args[1])), nil
+	}
+}
+
+// toList 将切片或数组转换为[]interface{}
+func toList(value interface{}) ([]interface{}, bool) {
+	if list, ok := value.([]interface{}); ok {
+		return list, true
+	}
+	if value == nil {
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// fnLen 字符串的字符数，或列表、映射的元素个数
+func fnLen(args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return 0.0, nil
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	}
+	rv := reflect.ValueOf(args[0])
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(rv.Len()), nil
+	default:
+		return nil, fmt.Errorf("无法获取 %v 的长度", args[0])
+	}
+}
+
+// fnContains 字符串是否包含子串、列表是否包含元素或映射是否包含键
+func fnContains(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return false, nil
+	}
+	found, err := containsValue(args[0], args[1], false)
+	if err != nil {
+		// 数值等其他类型按字符串判断
+		return strings.Contains(formatVariable(args[0]), formatVariable(args[1])), nil
+	}
+	return found, nil
+}
+
+// fnTrim 去除首尾空白，提供第二个参数时去除其中的字符
+func fnTrim(args []interface{}) (interface{}, error) {
+	text := formatVariable(args[0])
+	if len(args) == 2 {
+		return strings.Trim(text, formatVariable(args[1])), nil
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// fnReplace 替换字符串中所有的子串
+func fnReplace(args []interface{}) (interface{}, error) {
+	return strings.ReplaceAll(formatVariable(args[0]), formatVariable(args[1]), formatVariable(args[2])), nil
+}
+
+// fnSplit 按分隔符拆分字符串
+func fnSplit(args []interface{}) (interface{}, error) {
+	parts := strings.Split(formatVariable(args[0]), formatVariable(args[1]))
+	list := make([]interface{}, len(parts))
+	for i, part := range parts {
+		list[i] = part
+	}
+	return list, nil
+}
+
+// fnJoin 用分隔符连接列表元素，分隔符默认为逗号
+func fnJoin(args []interface{}) (interface{}, error) {
+	list, ok := toList(args[0])
+	if !ok {
+		return nil, fmt.Errorf("第一个参数必须是列表: %v", args[0])
+	}
+	separator := ","
+	if len(args) == 2 {
+		separator = formatVariable(args[1])
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		parts[i] = formatVariable(item)
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// numberPattern 匹配文本中的数字，支持千位分隔符，如 "共 1,234 条"
+var numberPattern = regexp.MustCompile(`[-+]?(\d{1,3}(,\d{3})+|\d+)(\.\d+)?`)
+
+// extractNumber 将值转换为数值，文本不是数字时提取其中的第一个数字
+func extractNumber(value interface{}) (float64, error) {
+	if num, ok := toNumber(value); ok {
+		return num, nil
+	}
+	text := formatVariable(value)
+	match := numberPattern.FindString(text)
+	if match == "" {
+		return 0, fmt.Errorf("无法从 '%s' 中提取数字", text)
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(match, ",", ""), 64)
+}
+
+// fnInt 转换为整数（截断小数部分），如 int('共 12 条') 为 12
+func fnInt(args []interface{}) (interface{}, error) {
+	num, err := extractNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return math.Trunc(num), nil
+}
+
+// fnFloat 转换为数值，如 float('¥4,799.50') 为 4799.5
+func fnFloat(args []interface{}) (interface{}, error) {
+	return extractNumber(args[0])
+}
+
+// fnStr 转换为字符串
+func fnStr(args []interface{}) (interface{}, error) {
+	return formatVariable(args[0]), nil
+}
+
+// regexCache 已编译的正则表达式缓存，可在并行分支间共享
+var regexCache sync.Map
+
+// compileRegex 编译正则表达式，结果会被缓存
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := regexCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式 '%s': %w", pattern, err)
+	}
+	regexCache.Store(pattern, re)
+	return re, nil
+}
+
+// fnMatches 文本是否匹配正则表达式
+func fnMatches(args []interface{}) (interface{}, error) {
+	re, err := compileRegex(formatVariable(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(formatVariable(args[0])), nil
+}
+
+// fnMatch 返回文本第一次匹配正则表达式的捕获组列表，没有捕获组时列表只包含整个匹配，未匹配时返回空列表
+func fnMatch(args []interface{}) (interface{}, error) {
+	re, err := compileRegex(formatVariable(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	groups := captureGroups(re, formatVariable(args[0]))
+	if groups == nil {
+		return []interface{}{}, nil
+	}
+	return groups, nil
+}
+
+// captureGroups 获取第一次匹配的捕获组，没有捕获组时返回整个匹配，未匹配时返回nil
+func captureGroups(re *regexp.Regexp, text string) []interface{} {
+	match := re.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	if len(match) == 1 {
+		return []interface{}{match[0]}
+	}
+	groups := make([]interface{}, 0, len(match)-1)
+	for _, group := range match[1:] {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// fnNow 当前时间
+func fnNow(args []interface{}) (interface{}, error) {
+	return time.Now(), nil
+}
+
+// DefaultDateLayout formatDate未指定格式时使用的日期格式
+const DefaultDateLayout = "2006-01-02 15:04:05"
+
+// dateLayouts 解析日期字符串时依次尝试的格式
+var dateLayouts = []string{time.RFC3339, DefaultDateLayout, "2006-01-02 15:04", "2006-01-02", "2006/01/02 15:04:05", "2006/01/02"}
+
+// dateLayoutReplacer 将 YYYY-MM-DD HH:mm:ss 形式的格式转换为Go的日期格式
+var dateLayoutReplacer = strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02", "HH", "15", "mm", "04", "ss", "05")
+
+// toTime 将时间、Unix时间戳(秒)或日期字符串转换为时间
+func toTime(value interface{}) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+	if seconds, ok := toNumber(value); ok {
+		return time.Unix(int64(seconds), 0), nil
+	}
+	return parseDate(formatVariable(value))
+}
+
+// parseDate 按dateLayouts中的格式解析日期字符串，不接受时间戳
+func parseDate(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析日期: %s", text)
+}
+
+// fnFormatDate 格式化日期，格式支持 YYYY-MM-DD HH:mm:ss 或Go的日期格式，默认为 2006-01-02 15:04:05
+func fnFormatDate(args []interface{}) (interface{}, error) {
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	layout := DefaultDateLayout
+	if len(args) == 2 {
+		layout = dateLayoutReplacer.Replace(formatVariable(args[1]))
+	}
+	return t.Format(layout), nil
+}
+
+// extremum 返回求最小值或最大值的函数，参数可以是多个数值或一个列表
+func extremum(operator string) Function {
+	return func(args []interface{}) (interface{}, error) {
+		values := args
+		if len(args) == 1 {
+			if list, ok := toList(args[0]); ok {
+				values = list
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("列表为空")
+		}
+
+		var result float64
+		for i, value := range values {
+			num, ok := toNumber(value)
+			if !ok {
+				return nil, fmt.Errorf("参数不是数值: %v", value)
+			}
+			if i == 0 || (operator == OpLT && num < result) || (operator == OpGT && num > result) {
+				result = num
+			}
+		}
+		return result, nil
+	}
+}
+
+// fnRound 四舍五入，第二个参数为保留的小数位数，默认为0
+func fnRound(args []interface{}) (interface{}, error) {
+	num, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("参数不是数值: %v", args[0])
+	}
+	digits := 0.0
+	if len(args) == 2 {
+		if digits, ok = toNumber(args[1]); !ok {
+			return nil, fmt.Errorf("小数位数不是数值: %v", args[1])
+		}
+	}
+	scale := math.Pow(10, math.Trunc(digits))
+	return math.Round(num*scale) / scale, nil
+}
+
+// fnDefault 值为空（未定义、nil或空字符串）时返回备用值
+func fnDefault(args []interface{}) (interface{}, error) {
+	if args[0] == nil || args[0] == "" {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+// fnUUID 生成随机的UUID(v4)
+func fnUUID(args []interface{}) (interface{}, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("生成UUID失败: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,165 @@
+package operator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VariableSelectors 共享选择器所在的变量名，通过{{selectors.名称}}引用
+const VariableSelectors = "selectors"
+
+// resolveRelativePath 相对路径基于所在文件的目录解析
+func resolveRelativePath(baseFile, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(filepath.Dir(baseFile), path)
+}
+
+// checkIncludeCycle 检查文件是否已在引入链中，存在循环时返回包含完整引入链的错误
+func checkIncludeCycle(chain []string, path string) error {
+	target, err := filepath.Abs(path)
+	if err != nil {
+		target = path
+	}
+	for _, file := range chain {
+		current, err := filepath.Abs(file)
+		if err != nil {
+			current = file
+		}
+		if current == target {
+			return fmt.Errorf("检测到循环引入: %s", strings.Join(append(append([]string{}, chain...), path), " -> "))
+		}
+	}
+	return nil
+}
+
+// expandIncludes 展开节点序列中的include节点，并记录每个节点所在的文件
+func expandIncludes(items []NodeItem, source string, chain []string) ([]NodeItem, error) {
+	expanded := make([]NodeItem, 0, len(items))
+	for _, item := range items {
+		if item.Source == "" {
+			item.Source = source
+		}
+		if item.Action != nil && item.Action.Source == "" {
+			item.Action.Source = item.Source
+		}
+
+		if item.Include != "" {
+			fragment, err := loadFragment(resolveRelativePath(source, item.Include), chain)
+			if err != nil {
+				return nil, fmt.Errorf("%s: include %s 失败: %w", item.Location(), item.Include, err)
+			}
+			expanded = append(expanded, fragment...)
+			continue
+		}
+
+		if item.IsControlNode() {
+			node := item.ControlNode
+			var err error
+			if node.Children, err = expandIncludes(node.Children, source, chain); err != nil {
+				return nil, err
+			}
+			if node.Catch, err = expandIncludes(node.Catch, source, chain); err != nil {
+				return nil, err
+			}
+			if node.Finally, err = expandIncludes(node.Finally, source, chain); err != nil {
+				return nil, err
+			}
+		}
+
+		expanded = append(expanded, item)
+	}
+	return expanded, nil
+}
+
+// loadFragment 加载被引入的任务片段文件，文件内容为操作序列
+func loadFragment(path string, chain []string) ([]NodeItem, error) {
+	if err := checkIncludeCycle(chain, path); err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	var items []NodeItem
+	if err := yaml.Unmarshal(content, &items); err != nil {
+		return nil, fmt.Errorf("解析文件 %s 失败: %w", path, err)
+	}
+
+	return expandIncludes(items, path, append(append([]string{}, chain...), path))
+}
+
+// expandProcedureIncludes 展开过程体中的include节点
+func expandProcedureIncludes(procedures []Procedure, source string, chain []string) error {
+	for i := range procedures {
+		actions, err := expandIncludes(procedures[i].Actions, source, chain)
+		if err != nil {
+			return fmt.Errorf("过程 %s: %w", procedures[i].Name, err)
+		}
+		procedures[i].Actions = actions
+	}
+	return nil
+}
+
+// loadImports 加载imports引入的共享过程和选择器，被导入的文件也可以继续导入其他文件；
+// loaded记录整个导入过程中已加载的文件，多个文件导入同一文件时只加载一次
+func loadImports(file *TaskFile, source string, chain []string, loaded map[string]bool) ([]Procedure, map[string]string, error) {
+	var procedures []Procedure
+	selectors := make(map[string]string)
+
+	for _, importPath := range file.Imports {
+		path := resolveRelativePath(source, importPath)
+		if err := checkIncludeCycle(chain, path); err != nil {
+			return nil, nil, fmt.Errorf("%s: import %s 失败: %w", source, importPath, err)
+		}
+
+		key, err := filepath.Abs(path)
+		if err != nil {
+			key = path
+		}
+		if loaded[key] {
+			continue
+		}
+		loaded[key] = true
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: 读取导入文件 %s 失败: %w", source, importPath, err)
+		}
+
+		var imported TaskFile
+		if err := yaml.Unmarshal(content, &imported); err != nil {
+			return nil, nil, fmt.Errorf("解析导入文件 %s 失败: %w", path, err)
+		}
+		if len(imported.Tasks) > 0 {
+			return nil, nil, fmt.Errorf("导入文件 %s 只能包含procedures、selectors和imports，不能包含tasks", path)
+		}
+
+		importedChain := append(append([]string{}, chain...), path)
+		nestedProcedures, nestedSelectors, err := loadImports(&imported, path, importedChain, loaded)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := expandProcedureIncludes(imported.Procedures, path, importedChain); err != nil {
+			return nil, nil, fmt.Errorf("导入文件 %s: %w", path, err)
+		}
+
+		procedures = append(procedures, nestedProcedures...)
+		procedures = append(procedures, imported.Procedures...)
+		for name, selector := range nestedSelectors {
+			selectors[name] = selector
+		}
+		for name, selector := range imported.Selectors {
+			selectors[name] = selector
+		}
+	}
+
+	return procedures, selectors, nil
+}
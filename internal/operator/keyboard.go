@@ -0,0 +1,103 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// keyAliases 按键名称的别名，不区分大小写，未列出的按键原样传给Playwright
+var keyAliases = map[string]string{
+	"ctrl":          "Control",
+	"control":       "Control",
+	"shift":         "Shift",
+	"alt":           "Alt",
+	"option":        "Alt",
+	"meta":          "Meta",
+	"cmd":           "Meta",
+	"command":       "Meta",
+	"controlormeta": "ControlOrMeta",
+	"enter":         "Enter",
+	"tab":           "Tab",
+	"esc":           "Escape",
+	"escape":        "Escape",
+	"backspace":     "Backspace",
+	"delete":        "Delete",
+	"up":            "ArrowUp",
+	"down":          "ArrowDown",
+	"left":          "ArrowLeft",
+	"right":         "ArrowRight",
+	"home":          "Home",
+	"end":           "End",
+	"pageup":        "PageUp",
+	"pagedown":      "PageDown",
+}
+
+// normalizeKey 规范化按键或组合键，按键名称支持小写和别名，如 ctrl+shift+enter 转换为 Control+Shift+Enter
+func normalizeKey(key string) string {
+	parts := strings.Split(key, "+")
+	// 以 + 结尾时最后一个键是加号本身，如 Shift++
+	if len(parts) > 1 && parts[len(parts)-1] == "" {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if alias, exists := keyAliases[strings.ToLower(part)]; exists {
+			part = alias
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "+")
+}
+
+// isKeyboardType 检查操作是否为键盘操作
+func isKeyboardType(actionType ActionType) bool {
+	switch actionType {
+	case ActionTypeText, ActionPress, ActionKeyDown, ActionKeyUp:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateKeyboard 验证键盘操作的参数
+func validateKeyboard(action *Action) error {
+	if !isKeyboardType(action.Type) {
+		if action.Key != "" {
+			return fmt.Errorf("%s操作不支持key参数", action.Type)
+		}
+		return nil
+	}
+
+	if action.Type == ActionTypeText {
+		if action.Value == "" {
+			return fmt.Errorf("type操作需要提供value参数")
+		}
+		if action.Delay < 0 {
+			return fmt.Errorf("type操作的delay不能为负数: %d", action.Delay)
+		}
+		return nil
+	}
+	if action.Key == "" {
+		return fmt.Errorf("%s操作需要提供key参数", action.Type)
+	}
+	return nil
+}
+
+// pressKeys 执行键盘操作，selector不为空时先聚焦该元素；delay为type逐字输入的字符间隔
+func (ce *ControlExecutor) pressKeys(actionType ActionType, selector, value, key string, delay time.Duration) error {
+	bm := ce.TaskManager.BrowserManager
+	switch actionType {
+	case ActionTypeText:
+		return bm.TypeText(selector, value, delay)
+	case ActionPress:
+		return bm.PressKey(selector, normalizeKey(key))
+	case ActionKeyDown:
+		return bm.KeyDown(selector, normalizeKey(key))
+	case ActionKeyUp:
+		return bm.KeyUp(selector, normalizeKey(key))
+	default:
+		return fmt.Errorf("不支持的键盘操作: %s", actionType)
+	}
+}
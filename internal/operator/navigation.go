@@ -0,0 +1,108 @@
+package operator
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// 导航完成的判断条件，对应wait_until参数
+const (
+	WaitUntilLoad             = "load"             // 页面load事件触发
+	WaitUntilDOMContentLoaded = "domcontentloaded" // DOMContentLoaded事件触发
+	WaitUntilNetworkIdle      = "networkidle"      // 至少500毫秒没有网络请求，默认
+	WaitUntilCommit           = "commit"           // 收到响应并开始加载文档
+)
+
+// 导航后存储当前页面信息的变量名
+const (
+	VariablePageURL   = "page_url"
+	VariablePageTitle = "page_title"
+)
+
+// waitUntilStates wait_until参数对应的Playwright等待状态
+var waitUntilStates = map[string]*playwright.WaitUntilState{
+	WaitUntilLoad:             playwright.WaitUntilStateLoad,
+	WaitUntilDOMContentLoaded: playwright.WaitUntilStateDomcontentloaded,
+	WaitUntilNetworkIdle:      playwright.WaitUntilStateNetworkidle,
+	WaitUntilCommit:           playwright.WaitUntilStateCommit,
+}
+
+// waitUntilState 解析导航完成的判断条件，为空时等待网络空闲
+func waitUntilState(name string) (*playwright.WaitUntilState, error) {
+	if name == "" {
+		name = WaitUntilNetworkIdle
+	}
+	state, exists := waitUntilStates[name]
+	if !exists {
+		return nil, fmt.Errorf("无效的wait_until: %s，可选值为 load、domcontentloaded、networkidle、commit", name)
+	}
+	return state, nil
+}
+
+// isNavigationType 检查操作是否为页面导航操作
+func isNavigationType(actionType ActionType) bool {
+	switch actionType {
+	case ActionGoto, ActionReload, ActionGoBack, ActionGoForward:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateNavigation 验证导航操作的参数
+func validateNavigation(action *Action) error {
+	if action.Type == ActionGoto && action.URL == "" {
+		return fmt.Errorf("goto操作需要提供url参数")
+	}
+	if action.WaitUntil == "" {
+		return nil
+	}
+	if !isNavigationType(action.Type) {
+		return fmt.Errorf("%s操作不支持wait_until参数", action.Type)
+	}
+	_, err := waitUntilState(action.WaitUntil)
+	return err
+}
+
+// navigate 执行导航操作，完成后更新当前页面的地址和标题变量；timeout为0时使用Playwright的默认超时
+func (ce *ControlExecutor) navigate(actionType ActionType, address, waitUntil string, timeout time.Duration) error {
+	bm := ce.TaskManager.BrowserManager
+
+	var err error
+	switch actionType {
+	case ActionGoto:
+		if address == "" {
+			return fmt.Errorf("goto操作需要提供url参数")
+		}
+		err = bm.Goto(address, waitUntil, timeout)
+	case ActionReload:
+		err = bm.Reload(waitUntil, timeout)
+	case ActionGoBack:
+		err = bm.GoBack(waitUntil, timeout)
+	case ActionGoForward:
+		err = bm.GoForward(waitUntil, timeout)
+	default:
+		return fmt.Errorf("不支持的导航操作: %s", actionType)
+	}
+	if err != nil {
+		return err
+	}
+
+	ce.recordPageInfo()
+	log.Printf("🧭 %s完成，当前页面: %s", actionType, ce.Context.GetVariable(VariablePageURL))
+	return nil
+}
+
+// recordPageInfo 将当前页面的地址和标题存入page_url和page_title变量
+func (ce *ControlExecutor) recordPageInfo() {
+	address, title, err := ce.TaskManager.BrowserManager.PageInfo()
+	if err != nil {
+		log.Printf("⚠️  获取页面信息失败: %v", err)
+		return
+	}
+	ce.Context.SetVariable(VariablePageURL, address)
+	ce.Context.SetVariable(VariablePageTitle, title)
+}
@@ -0,0 +1,192 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+)
+
+// 并行分支的隔离方式
+const (
+	IsolationPage    = "page"    // 同一浏览器上下文中的新页面，共享Cookie和存储
+	IsolationContext = "context" // 独立的浏览器上下文，Cookie和存储互不共享
+)
+
+// errBranchCancelled 并行分支因其他分支失败而被取消
+var errBranchCancelled = errors.New("并行分支已取消")
+
+// branchResult 并行分支的执行结果
+type branchResult struct {
+	name    string
+	context *ExecutionContext      // 分支的执行上下文
+	seed    map[string]interface{} // 分支开始时复制的变量，用于找出分支修改过的变量
+	err     error
+}
+
+// visibleVariables 收集当前可见的所有变量，内层作用域覆盖外层
+func (ec *ExecutionContext) visibleVariables() map[string]interface{} {
+	variables := make(map[string]interface{}, len(ec.Variables))
+	for name, value := range ec.Variables {
+		variables[name] = value
+	}
+	for _, scope := range ec.scopes {
+		for name, value := range scope {
+			variables[name] = value
+		}
+	}
+	return variables
+}
+
+// branchName 获取分支名称，未命名的分支按序号命名
+func branchName(branch *ControlNode, index int) string {
+	if branch.Name != "" {
+		return branch.Name
+	}
+	return fmt.Sprintf("分支%d", index+1)
+}
+
+// executeParallel 执行parallel节点：每个分支在独立的页面或浏览器上下文中同时运行，
+// 全部成功后按分支顺序合并变量；任一分支失败时取消其他分支
+func (ce *ControlExecutor) executeParallel(node *ControlNode) error {
+	parent := ce.TaskManager.BrowserManager
+	if parent == nil {
+		return fmt.Errorf("浏览器未初始化，无法并行执行")
+	}
+	isolated := node.Isolation == IsolationContext
+
+	startURL := ""
+	if parent.Page != nil {
+		startURL = parent.Page.URL()
+	}
+
+	// 先为所有分支创建会话，任何一个失败都不启动分支
+	sessions := make([]*BrowserManager, 0, len(node.Children))
+	defer func() {
+		for _, session := range sessions {
+			session.CloseSession()
+		}
+	}()
+	for i, child := range node.Children {
+		session, err := parent.NewSession(isolated)
+		if err != nil {
+			return fmt.Errorf("为并行分支 %s 创建会话失败: %w", branchName(child.ControlNode, i), err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	ctx, cancel := context.WithCancel(ce.ctx)
+	defer cancel()
+
+	log.Printf("🔀 开始并行执行 %d 个分支", len(node.Children))
+
+	// 分支开始时复制调用方当前可见的变量，执行期间调用方的变量不会被修改
+	seed := ce.Context.visibleVariables()
+
+	results := make([]branchResult, len(node.Children))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i, child := range node.Children {
+		wg.Add(1)
+		go func(i int, branch *ControlNode) {
+			defer wg.Done()
+			results[i] = ce.runBranch(ctx, branch, branchName(branch, i), sessions[i], startURL, seed)
+			if results[i].err == nil || errors.Is(results[i].err, errBranchCancelled) {
+				return
+			}
+			once.Do(func() {
+				firstErr = results[i].err
+				cancel()
+				// 关闭其他分支的页面，中断正在等待中的浏览器操作
+				for j, session := range sessions {
+					if j != i {
+						session.CloseSession()
+					}
+				}
+			})
+		}(i, child.ControlNode)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		ce.Context.Attempts = append(ce.Context.Attempts, result.context.Attempts...)
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ce.ctx.Err(); err != nil {
+		return errBranchCancelled
+	}
+
+	// 按分支顺序合并变量，多个分支修改同一变量时以后面的分支为准；页面信息变量属于各分支自己的页面，不合并
+	writers := make(map[string]string)
+	for _, result := range results {
+		for name, value := range result.context.Variables {
+			if name == VariablePageURL || name == VariablePageTitle {
+				continue
+			}
+			if original, existed := result.seed[name]; existed && reflect.DeepEqual(original, value) {
+				continue
+			}
+			if writer, written := writers[name]; written {
+				log.Printf("⚠️  并行分支 %s 和 %s 都修改了变量 %s，使用 %s 的值", writer, result.name, name, result.name)
+			}
+			writers[name] = result.name
+			ce.Context.SetVariable(name, value)
+		}
+	}
+
+	log.Printf("🔀 并行执行完成，共 %d 个分支", len(node.Children))
+	return nil
+}
+
+// runBranch 在独立的执行器中运行一个并行分支
+func (ce *ControlExecutor) runBranch(ctx context.Context, branch *ControlNode, name string, session *BrowserManager, startURL string, seed map[string]interface{}) branchResult {
+	executor := NewControlExecutor(NewTaskManager(session))
+	executor.ctx = ctx
+	executor.Procedures = ce.Procedures
+	executor.callStack = append([]string{}, ce.callStack...)
+	executor.Context.Strict = ce.Context.Strict
+
+	for variableName, value := range seed {
+		executor.Context.Variables[variableName] = value
+	}
+	result := branchResult{name: name, context: executor.Context, seed: seed}
+
+	url := startURL
+	if branch.URL != "" {
+		rendered, err := executor.replaceVariables(branch.URL)
+		if err != nil {
+			result.err = fmt.Errorf("并行分支 %s 的url模板替换失败: %w", name, err)
+			return result
+		}
+		url = rendered
+	}
+	if url != "" && url != "about:blank" {
+		if err := session.Navigate(url); err != nil {
+			result.err = fmt.Errorf("并行分支 %s 导航失败: %w", name, err)
+			return result
+		}
+	}
+
+	executor.recordPageInfo()
+
+	log.Printf("🔀 分支 %s 开始执行", name)
+	if err := executor.ExecuteNodeItems(branch.Children); err != nil {
+		if ctx.Err() != nil {
+			result.err = errBranchCancelled
+			log.Printf("🔀 分支 %s 已取消", name)
+		} else {
+			result.err = fmt.Errorf("并行分支 %s 执行失败: %w", name, err)
+		}
+		return result
+	}
+
+	log.Printf("🔀 分支 %s 执行完成", name)
+	return result
+}
@@ -0,0 +1,285 @@
+package operator
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxCallDepth 过程调用的最大嵌套深度，防止无限递归
+const MaxCallDepth = 32
+
+// sharedVariables 过程可以读取的调用方变量：共享选择器和当前页面信息
+var sharedVariables = []string{VariableSelectors, VariablePageURL, VariablePageTitle}
+
+// Procedure 可复用的过程定义
+type Procedure struct {
+	Name     string                 `json:"name" yaml:"name"`                             // 过程名称
+	Params   []string               `json:"params,omitempty" yaml:"params,omitempty"`     // 参数名列表
+	Defaults map[string]interface{} `json:"defaults,omitempty" yaml:"defaults,omitempty"` // 参数默认值，未提供默认值的参数为必填参数
+	Returns  []string               `json:"returns,omitempty" yaml:"returns,omitempty"`   // 执行结束后返回给调用方的变量名
+	Actions  []NodeItem             `json:"actions" yaml:"actions"`                       // 过程体
+}
+
+// TaskFile 任务文件的完整格式，顶层为映射时使用
+type TaskFile struct {
+	Imports    []string          `json:"imports,omitempty" yaml:"imports,omitempty"`       // 导入共享过程和选择器的文件，相对路径基于当前文件
+	Selectors  map[string]string `json:"selectors,omitempty" yaml:"selectors,omitempty"`   // 共享的选择器，通过{{selectors.名称}}引用
+	Procedures []Procedure       `json:"procedures,omitempty" yaml:"procedures,omitempty"` // 文件内所有任务共享的过程
+	Tasks      []Task            `json:"tasks" yaml:"tasks"`                               // 任务列表
+}
+
+// Validate 验证过程定义
+func (p *Procedure) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("过程必须提供name名称")
+	}
+	if len(p.Actions) == 0 {
+		return fmt.Errorf("过程 %s 必须包含至少一个操作", p.Name)
+	}
+
+	params := make(map[string]bool, len(p.Params))
+	for _, param := range p.Params {
+		if param == "" {
+			return fmt.Errorf("过程 %s 的参数名不能为空", p.Name)
+		}
+		if params[param] {
+			return fmt.Errorf("过程 %s 的参数 %s 重复", p.Name, param)
+		}
+		params[param] = true
+	}
+	for name := range p.Defaults {
+		if !params[name] {
+			return fmt.Errorf("过程 %s 为未声明的参数 %s 提供了默认值", p.Name, name)
+		}
+	}
+	for _, name := range p.Returns {
+		if name == "" {
+			return fmt.Errorf("过程 %s 的返回变量名不能为空", p.Name)
+		}
+	}
+
+	// 过程体以define为父节点验证，其中嵌套的define会报错
+	if err := validateNodeItems(p.Actions, ControlTypeDefine, loopScope{}); err != nil {
+		return fmt.Errorf("过程 %s 配置无效: %w", p.Name, err)
+	}
+	return nil
+}
+
+// validateArgs 验证调用参数：不允许未声明的参数，必填参数必须提供
+func (p *Procedure) validateArgs(args map[string]interface{}) error {
+	declared := make(map[string]bool, len(p.Params))
+	for _, param := range p.Params {
+		declared[param] = true
+		if _, provided := args[param]; provided {
+			continue
+		}
+		if _, hasDefault := p.Defaults[param]; !hasDefault {
+			return fmt.Errorf("调用过程 %s 缺少必填参数: %s", p.Name, param)
+		}
+	}
+	for name := range args {
+		if !declared[name] {
+			return fmt.Errorf("调用过程 %s 传入了未声明的参数: %s", p.Name, name)
+		}
+	}
+	return nil
+}
+
+// procedureFromNode 将define节点转换为过程定义
+func procedureFromNode(node *ControlNode) Procedure {
+	return Procedure{
+		Name:     node.Name,
+		Params:   node.Params,
+		Defaults: node.Defaults,
+		Returns:  node.Returns,
+		Actions:  node.Children,
+	}
+}
+
+// registerProcedures 将过程加入过程表，名称重复时报错
+func registerProcedures(table map[string]*Procedure, procedures []Procedure) error {
+	for i := range procedures {
+		procedure := &procedures[i]
+		if err := procedure.Validate(); err != nil {
+			return err
+		}
+		if _, exists := table[procedure.Name]; exists {
+			return fmt.Errorf("过程 %s 重复定义", procedure.Name)
+		}
+		table[procedure.Name] = procedure
+	}
+	return nil
+}
+
+// resolveTaskProcedures 合并共享过程与任务内define节点定义的过程，并验证所有call节点
+func resolveTaskProcedures(task *Task, shared map[string]*Procedure) error {
+	table := make(map[string]*Procedure, len(shared))
+	for name, procedure := range shared {
+		table[name] = procedure
+	}
+
+	// define节点只能出现在任务操作序列的顶层
+	var defined []Procedure
+	for _, item := range task.Actions {
+		if item.IsControlNode() && item.ControlNode.Type == ControlTypeDefine {
+			defined = append(defined, procedureFromNode(item.ControlNode))
+		}
+	}
+	if err := registerProcedures(table, defined); err != nil {
+		return err
+	}
+
+	// define节点的过程体随任务操作序列一起验证
+	if err := validateCalls(task.Actions, table); err != nil {
+		return err
+	}
+
+	task.Procedures = table
+	return nil
+}
+
+// validateCalls 递归验证call节点引用的过程存在且参数匹配
+func validateCalls(items []NodeItem, procedures map[string]*Procedure) error {
+	for i, item := range items {
+		if !item.IsControlNode() {
+			continue
+		}
+
+		node := item.ControlNode
+		if node.Type == ControlTypeCall {
+			procedure, exists := procedures[node.Procedure]
+			if !exists {
+				return fmt.Errorf("第%d个节点%s: 调用了未定义的过程: %s", i+1, locationSuffix(item), node.Procedure)
+			}
+			if err := procedure.validateArgs(node.Args); err != nil {
+				return fmt.Errorf("第%d个节点%s: %w", i+1, locationSuffix(item), err)
+			}
+			for _, name := range node.Output {
+				if !containsString(procedure.Returns, name) {
+					return fmt.Errorf("第%d个节点%s: 过程 %s 没有返回变量 %s", i+1, locationSuffix(item), procedure.Name, name)
+				}
+			}
+		}
+
+		for _, children := range [][]NodeItem{node.Children, node.Catch, node.Finally} {
+			if err := validateCalls(children, procedures); err != nil {
+				return fmt.Errorf("第%d个%s节点%s的子节点: %w", i+1, node.Type, locationSuffix(item), err)
+			}
+		}
+	}
+	return nil
+}
+
+// containsString 检查字符串切片中是否包含指定值
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadProceduresFromFile 从过程库文件加载过程定义，文件可以是过程列表或包含procedures的映射
+func LoadProceduresFromFile(filename string) ([]Procedure, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取过程库文件失败: %w", err)
+	}
+
+	var procedures []Procedure
+	if err := yaml.Unmarshal(content, &procedures); err != nil {
+		var library TaskFile
+		if err := yaml.Unmarshal(content, &library); err != nil {
+			return nil, fmt.Errorf("过程库YAML解码失败 (文件: %s): %w", filename, err)
+		}
+		procedures = library.Procedures
+	}
+
+	if err := expandProcedureIncludes(procedures, filename, []string{filename}); err != nil {
+		return nil, fmt.Errorf("过程库 %s: %w", filename, err)
+	}
+	return procedures, nil
+}
+
+// executeCall 执行call节点：在独立的执行上下文中运行过程，结束后将返回变量写回调用方
+func (ce *ControlExecutor) executeCall(node *ControlNode) error {
+	procedure, exists := ce.Procedures[node.Procedure]
+	if !exists {
+		return fmt.Errorf("调用了未定义的过程: %s", node.Procedure)
+	}
+	if len(ce.callStack) >= MaxCallDepth {
+		return fmt.Errorf("过程调用深度超过上限 %d: %s", MaxCallDepth, strings.Join(append(ce.callStack, procedure.Name), " -> "))
+	}
+	if err := procedure.validateArgs(node.Args); err != nil {
+		return err
+	}
+
+	// 在调用方上下文中求值参数，模板变量按调用方作用域替换；参数只有一个 {{ }} 时保留原始类型
+	procContext := NewExecutionContext()
+	procContext.Strict = ce.Context.Strict
+	for _, name := range sharedVariables {
+		if value, exists := ce.Context.LookupVariable(name); exists {
+			procContext.SetVariable(name, value)
+		}
+	}
+	for name, value := range procedure.Defaults {
+		procContext.SetVariable(name, value)
+	}
+	for name, value := range node.Args {
+		if text, ok := value.(string); ok {
+			rendered, err := renderTemplateValue(text, ce.Context)
+			if err != nil {
+				return fmt.Errorf("调用过程 %s 的参数 %s 求值失败: %w", procedure.Name, name, err)
+			}
+			value = rendered
+		}
+		procContext.SetVariable(name, value)
+	}
+
+	log.Printf("📞 调用过程: %s", procedure.Name)
+
+	// 过程拥有独立的变量和循环栈，break/continue不会越过过程边界
+	callerContext, callerLoops := ce.Context, ce.LoopStack
+	ce.Context, ce.LoopStack = procContext, make([]string, 0)
+	ce.callStack = append(ce.callStack, procedure.Name)
+
+	err := ce.ExecuteNodeItems(procedure.Actions)
+
+	ce.callStack = ce.callStack[:len(ce.callStack)-1]
+	ce.Context, ce.LoopStack = callerContext, callerLoops
+	ce.Context.Attempts = append(ce.Context.Attempts, procContext.Attempts...)
+
+	// 过程中的导航会改变当前页面，页面信息同步回调用方
+	for _, name := range []string{VariablePageURL, VariablePageTitle} {
+		if value, exists := procContext.LookupVariable(name); exists {
+			ce.Context.SetVariable(name, value)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("过程 %s 执行失败: %w", procedure.Name, err)
+	}
+
+	// 返回变量写回调用方，output可将返回变量映射为调用方的其他变量名
+	for _, name := range procedure.Returns {
+		value, exists := procContext.LookupVariable(name)
+		if !exists {
+			return fmt.Errorf("过程 %s 未设置返回变量: %s", procedure.Name, name)
+		}
+		target := name
+		for callerName, returnName := range node.Output {
+			if returnName == name {
+				target = callerName
+			}
+		}
+		ce.Context.SetVariable(target, value)
+	}
+
+	log.Printf("📞 过程执行完成: %s", procedure.Name)
+	return nil
+}
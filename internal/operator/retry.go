@@ -0,0 +1,198 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mike/auto-go/internal/logger"
+	"github.com/playwright-community/playwright-go"
+)
+
+// 重试退避策略
+const (
+	BackoffFixed       = "fixed"
+	BackoffExponential = "exponential"
+)
+
+// 错误类型，用于重试策略的on过滤
+const (
+	ErrorKindTimeout    = "timeout"
+	ErrorKindNotFound   = "not_found"
+	ErrorKindNavigation = "navigation"
+	ErrorKindOther      = "other"
+)
+
+// 重试策略默认值
+const (
+	DefaultRetryAttempts = 3
+	DefaultRetryDelay    = 1000 // 毫秒
+	MaxRetryAttempts     = 20
+	MaxRetryDelay        = 60000 // 毫秒，同时也是指数退避的等待上限
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	Attempts int      `json:"attempts,omitempty" yaml:"attempts,omitempty"` // 最大尝试次数（包含首次执行），默认为3
+	Delay    int      `json:"delay,omitempty" yaml:"delay,omitempty"`       // 重试前的等待时间(毫秒)，默认为1000
+	Backoff  string   `json:"backoff,omitempty" yaml:"backoff,omitempty"`   // 退避策略："fixed"或"exponential"，默认为fixed
+	On       []string `json:"on,omitempty" yaml:"on,omitempty"`             // 仅对这些类型的错误重试，为空时对所有错误重试
+}
+
+// AttemptRecord 单次尝试的执行记录，与任务结果中保存的记录相同
+type AttemptRecord = logger.AttemptRecord
+
+// ActionError 操作执行失败的错误，保留原始错误及其类型
+type ActionError struct {
+	Message string
+	Kind    string
+	Err     error
+}
+
+func (e *ActionError) Error() string {
+	return e.Message
+}
+
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// Validate 验证重试策略配置
+func (rp *RetryPolicy) Validate() error {
+	if rp.Attempts < 0 {
+		return fmt.Errorf("重试次数attempts不能为负数")
+	}
+	if rp.Attempts > MaxRetryAttempts {
+		return fmt.Errorf("重试次数attempts不能超过%d", MaxRetryAttempts)
+	}
+	if rp.Delay < 0 {
+		return fmt.Errorf("重试等待时间delay不能为负数")
+	}
+	if rp.Delay > MaxRetryDelay {
+		return fmt.Errorf("重试等待时间delay不能超过%d毫秒", MaxRetryDelay)
+	}
+	switch rp.Backoff {
+	case "", BackoffFixed, BackoffExponential:
+	default:
+		return fmt.Errorf("不支持的重试退避策略: %s", rp.Backoff)
+	}
+	for _, kind := range rp.On {
+		switch normalizeErrorKind(kind) {
+		case ErrorKindTimeout, ErrorKindNotFound, ErrorKindNavigation:
+		default:
+			return fmt.Errorf("不支持的重试错误类型: %s", kind)
+		}
+	}
+	return nil
+}
+
+// shouldRetry 检查该类型的错误是否需要重试
+func (rp *RetryPolicy) shouldRetry(kind string) bool {
+	if len(rp.On) == 0 {
+		return true
+	}
+	for _, on := range rp.On {
+		if normalizeErrorKind(on) == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// delayBefore 计算第attempt次尝试前的等待时间，指数退避不超过MaxRetryDelay
+func (rp *RetryPolicy) delayBefore(attempt int) time.Duration {
+	delay := rp.Delay
+	if delay == 0 {
+		delay = DefaultRetryDelay
+	}
+	wait := time.Duration(delay) * time.Millisecond
+	if rp.Backoff == BackoffExponential {
+		maxWait := time.Duration(MaxRetryDelay) * time.Millisecond
+		for i := 2; i < attempt && wait < maxWait; i++ {
+			wait *= 2
+		}
+		wait = min(wait, maxWait)
+	}
+	return wait
+}
+
+// normalizeErrorKind 统一错误类型写法，如 not-found 与 not_found
+func normalizeErrorKind(kind string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(kind)), "-", "_")
+}
+
+// ClassifyError 判断错误类型：超时、元素未找到、导航失败或其他
+func ClassifyError(err error) string {
+	var actionErr *ActionError
+	if errors.As(err, &actionErr) && actionErr.Kind != "" {
+		return actionErr.Kind
+	}
+
+	message := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, playwright.ErrTimeout) || strings.Contains(message, "timeout") || strings.Contains(message, "超时"):
+		return ErrorKindTimeout
+	case strings.Contains(message, "导航") || strings.Contains(message, "net::err") || strings.Contains(message, "navigation"):
+		return ErrorKindNavigation
+	case strings.Contains(message, "未找到") || strings.Contains(message, "not found") || strings.Contains(message, "no element") || strings.Contains(message, "resolved to 0 elements"):
+		return ErrorKindNotFound
+	default:
+		return ErrorKindOther
+	}
+}
+
+// isCancellation 检查错误是否由取消引起
+func isCancellation(err error) bool {
+	return errors.Is(err, errBranchCancelled) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// executeWithRetry 按重试策略执行，每次尝试都记录到执行上下文
+func (ce *ControlExecutor) executeWithRetry(target string, policy RetryPolicy, run func() error) error {
+	attempts := policy.Attempts
+	if attempts == 0 {
+		attempts = DefaultRetryAttempts
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			wait := policy.delayBefore(attempt)
+			log.Printf("🔁 %s 第%d次重试，等待 %v", target, attempt-1, wait)
+			select {
+			case <-time.After(wait):
+			case <-ce.ctx.Done():
+				return errBranchCancelled
+			}
+		}
+
+		err = run()
+		record := AttemptRecord{
+			Target:  target,
+			Attempt: attempt,
+			Success: err == nil,
+			Time:    time.Now().Format("2006-01-02 15:04:05"),
+		}
+		if err == nil {
+			ce.Context.RecordAttempt(record)
+			return nil
+		}
+
+		record.Kind = ClassifyError(err)
+		record.Error = err.Error()
+		ce.Context.RecordAttempt(record)
+
+		// 取消不是可恢复的失败，重试只会拖延分支的退出
+		if isCancellation(err) || ce.ctx.Err() != nil {
+			return err
+		}
+		if !policy.shouldRetry(record.Kind) {
+			log.Printf("⚠️  %s 失败，错误类型 %s 不在重试范围内", target, record.Kind)
+			return err
+		}
+	}
+
+	return fmt.Errorf("%s 重试%d次后仍然失败: %w", target, attempts, err)
+}
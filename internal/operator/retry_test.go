@@ -0,0 +1,196 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestRetryPolicyDelayBefore(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"默认等待时间", RetryPolicy{}, 2, time.Second},
+		{"固定间隔", RetryPolicy{Delay: 200}, 5, 200 * time.Millisecond},
+		{"指数退避第一次重试", RetryPolicy{Delay: 100, Backoff: BackoffExponential}, 2, 100 * time.Millisecond},
+		{"指数退避每次翻倍", RetryPolicy{Delay: 100, Backoff: BackoffExponential}, 4, 400 * time.Millisecond},
+		{"指数退避不超过上限", RetryPolicy{Delay: 1000, Backoff: BackoffExponential}, 10, time.Minute},
+		{"尝试次数很大时不溢出", RetryPolicy{Delay: 1000, Backoff: BackoffExponential}, 1000, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.delayBefore(tt.attempt); got != tt.want {
+				t.Errorf("delayBefore(%d) = %v，期望 %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		wantErr string // 为空表示期望验证通过
+	}{
+		{"完整配置", RetryPolicy{Attempts: 5, Delay: 500, Backoff: BackoffExponential, On: []string{"timeout", "not-found"}}, ""},
+		{"使用默认值", RetryPolicy{}, ""},
+		{"尝试次数为负数", RetryPolicy{Attempts: -1}, "重试次数attempts不能为负数"},
+		{"尝试次数超过上限", RetryPolicy{Attempts: MaxRetryAttempts + 1}, "重试次数attempts不能超过20"},
+		{"等待时间超过上限", RetryPolicy{Delay: MaxRetryDelay + 1}, "重试等待时间delay不能超过60000毫秒"},
+		{"不支持的退避策略", RetryPolicy{Backoff: "linear"}, "不支持的重试退避策略: linear"},
+		{"不支持的错误类型", RetryPolicy{On: []string{"other"}}, "不支持的重试错误类型: other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("验证返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"Playwright超时", fmt.Errorf("点击失败: %w", playwright.ErrTimeout), ErrorKindTimeout},
+		{"中文超时信息", errors.New("等待元素超时"), ErrorKindTimeout},
+		{"导航失败", errors.New("page.goto: net::ERR_CONNECTION_REFUSED"), ErrorKindNavigation},
+		{"元素未找到", errors.New("locator resolved to 0 elements"), ErrorKindNotFound},
+		{"操作错误自带类型", &ActionError{Message: "自定义错误", Kind: ErrorKindNavigation}, ErrorKindNavigation},
+		{"其他错误", errors.New("页面未初始化"), ErrorKindOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%q) = %s，期望 %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodes        string
+		wantAttempts int
+		wantErr      string
+	}{
+		{
+			name: "错误类型不在on中时不重试",
+			nodes: `
+- type: retry
+  attempts: 3
+  delay: 1
+  on: [timeout]
+  children:
+    - type: click
+      selector: "#submit"
+`,
+			wantAttempts: 1,
+			wantErr:      "页面未初始化",
+		},
+		{
+			name: "未设置on时对所有错误重试",
+			nodes: `
+- type: retry
+  delay: 1
+  children:
+    - type: click
+      selector: "#submit"
+`,
+			wantAttempts: DefaultRetryAttempts,
+			wantErr:      "重试3次后仍然失败",
+		},
+		{
+			name: "操作级重试",
+			nodes: `
+- type: click
+  selector: "#submit"
+  retry: {attempts: 2, delay: 1}
+`,
+			wantAttempts: 2,
+			wantErr:      "重试2次后仍然失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := parseTestNodes(t, tt.nodes)
+			if err := ValidateNodeItems(items); err != nil {
+				t.Fatalf("验证节点失败: %v", err)
+			}
+
+			executor := newTestExecutor(nil)
+			err := executor.ExecuteNodeItems(items)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+			}
+			if got := len(executor.Context.Attempts); got != tt.wantAttempts {
+				t.Errorf("尝试次数 = %d，期望 %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestExecuteWithRetryCancelled(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(cancel context.CancelFunc) error
+	}{
+		{
+			name: "嵌套的并行分支已取消",
+			run: func(cancel context.CancelFunc) error {
+				return fmt.Errorf("branch执行失败: %w", errBranchCancelled)
+			},
+		},
+		{
+			name: "执行中所在分支被取消",
+			run: func(cancel context.CancelFunc) error {
+				cancel()
+				return errors.New("等待元素超时")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := newTestExecutor(nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			executor.ctx = ctx
+
+			calls := 0
+			err := executor.executeWithRetry("test", RetryPolicy{Attempts: 5, Delay: 1}, func() error {
+				calls++
+				return tt.run(cancel)
+			})
+			if err == nil || strings.Contains(err.Error(), "重试") {
+				t.Fatalf("错误 = %v，期望直接返回取消前的错误", err)
+			}
+			if calls != 1 {
+				t.Errorf("执行次数 = %d，期望 1", calls)
+			}
+		})
+	}
+}
@@ -24,27 +24,61 @@ const (
 	ActionWaitDisappear ActionType = "wait_disappear"
 	ActionGetText       ActionType = "get_text"
 	ActionGetAttribute  ActionType = "get_attribute"
+	ActionCheck         ActionType = "check"
+	ActionUncheck       ActionType = "uncheck"
+	ActionGetValue      ActionType = "get_value"
+	ActionIsChecked     ActionType = "is_checked"
+	ActionTypeText      ActionType = "type"
+	ActionPress         ActionType = "press"
+	ActionKeyDown       ActionType = "key_down"
+	ActionKeyUp         ActionType = "key_up"
+	ActionGoto          ActionType = "goto"
+	ActionReload        ActionType = "reload"
+	ActionGoBack        ActionType = "go_back"
+	ActionGoForward     ActionType = "go_forward"
+	ActionUpload        ActionType = "upload"
+	ActionDownload      ActionType = "download"
+	ActionEvaluate      ActionType = "evaluate"
+	ActionBreak         ActionType = "break"
+	ActionContinue      ActionType = "continue"
 )
 
 // Action 定义单个元素操作
 type Action struct {
-	Type         ActionType `json:"type"`
-	Selector     string     `json:"selector"`
-	Value        string     `json:"value,omitempty"`
-	Target       string     `json:"target,omitempty"`        // 用于拖拽目标或其他需要第二个元素的场景
-	Attribute    string     `json:"attribute,omitempty"`     // 用于获取属性
-	Timeout      int        `json:"timeout,omitempty"`       // 超时时间(秒)，默认10秒
-	OutputKey    string     `json:"output_key,omitempty"`    // 用于存储操作结果的键名
-	ErrorMessage string     `json:"error_message,omitempty"` // 自定义错误信息
+	Type         ActionType   `json:"type" yaml:"type"`
+	Selector     string       `json:"selector" yaml:"selector"`
+	Value        string       `json:"value,omitempty" yaml:"value,omitempty"`
+	Target       string       `json:"target,omitempty" yaml:"target,omitempty"`               // 用于拖拽目标，或break/continue的目标循环标签
+	Attribute    string       `json:"attribute,omitempty" yaml:"attribute,omitempty"`         // 用于获取属性
+	Regex        string       `json:"regex,omitempty" yaml:"regex,omitempty"`                 // get_text只保存文本中匹配该正则表达式的部分
+	URL          string       `json:"url,omitempty" yaml:"url,omitempty"`                     // goto导航的地址，支持{{var}}模板和相对地址
+	WaitUntil    string       `json:"wait_until,omitempty" yaml:"wait_until,omitempty"`       // 导航完成的判断条件：load、domcontentloaded、networkidle(默认)或commit
+	Key          string       `json:"key,omitempty" yaml:"key,omitempty"`                     // press、key_down、key_up的按键或组合键，如 Enter、Control+A
+	Delay        int          `json:"delay,omitempty" yaml:"delay,omitempty"`                 // type逐字输入时每个字符之间的间隔(毫秒)
+	By           string       `json:"by,omitempty" yaml:"by,omitempty"`                       // select匹配选项的方式：label(默认)、value或index
+	Values       []string     `json:"values,omitempty" yaml:"values,omitempty"`               // select多选时的选项列表，支持{{var}}模板
+	Files        []string     `json:"files,omitempty" yaml:"files,omitempty"`                 // upload上传的文件，相对路径基于任务文件所在目录，支持{{var}}模板
+	SaveAs       string       `json:"save_as,omitempty" yaml:"save_as,omitempty"`             // download保存的文件名(相对于下载目录)，默认使用服务器建议的文件名
+	Script       string       `json:"script,omitempty" yaml:"script,omitempty"`               // evaluate执行的JavaScript，当前变量作为参数传入，不做{{var}}模板替换
+	Timeout      int          `json:"timeout,omitempty" yaml:"timeout,omitempty"`             // 超时时间(秒)，默认10秒
+	OutputKey    string       `json:"output_key,omitempty" yaml:"output_key,omitempty"`       // 用于存储操作结果的键名
+	ErrorMessage string       `json:"error_message,omitempty" yaml:"error_message,omitempty"` // 自定义错误信息
+	Retry        *RetryPolicy `json:"retry,omitempty" yaml:"retry,omitempty"`                 // 失败时的重试策略
+
+	Source string `json:"-" yaml:"-"` // 操作所在的文件，用于解析upload的相对路径
 }
 
 // Task 定义自动化任务
 type Task struct {
-	Name       string      `json:"name"`
-	URL        string      `json:"url"`
-	WaitTime   int         `json:"wait_time,omitempty"`
-	Screenshot bool        `json:"screenshot,omitempty"`
-	Actions    []NodeItem  `json:"actions"` // 灵活操作序列，支持流程控制
+	Name       string     `json:"name" yaml:"name"`
+	URL        string     `json:"url" yaml:"url"`
+	WaitTime   int        `json:"wait_time,omitempty" yaml:"wait_time,omitempty"`
+	Screenshot bool       `json:"screenshot,omitempty" yaml:"screenshot,omitempty"`
+	Actions    []NodeItem `json:"actions" yaml:"actions"`                   // 灵活操作序列，支持流程控制
+	Strict     bool       `json:"strict,omitempty" yaml:"strict,omitempty"` // 严格模式：表达式中的类型不匹配视为错误
+
+	Procedures map[string]*Procedure `json:"-" yaml:"-"` // 任务可调用的过程，加载时解析
+	Selectors  map[string]string     `json:"-" yaml:"-"` // 任务可引用的共享选择器，加载时解析
 }
 
 // TaskManager 管理自动化任务
@@ -90,8 +124,10 @@ func (tm *TaskManager) ExecuteTask(task Task) logger.TaskResult {
 	// 等待页面加载
 	time.Sleep(time.Duration(task.WaitTime) * time.Second)
 
-	// 执行操作序列
-	if err := tm.executeActions(task.Actions); err != nil {
+	// 执行操作序列，重试策略下的每次尝试随结果一起保存
+	attempts, err := tm.executeActions(task)
+	result.Attempts = attempts
+	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("执行操作序列失败: %v", err)
 		return result
@@ -118,17 +154,40 @@ func (tm *TaskManager) ExecuteTask(task Task) logger.TaskResult {
 	return result
 }
 
-// executeActions 执行操作序列（支持流程控制）
-func (tm *TaskManager) executeActions(items []NodeItem) error {
+// executeActions 执行操作序列（支持流程控制），返回重试策略下的每次尝试记录
+func (tm *TaskManager) executeActions(task Task) ([]AttemptRecord, error) {
 	// 创建控制执行器
 	executor := NewControlExecutor(tm)
-	
+	executor.Context.Strict = task.Strict
+	for name, procedure := range task.Procedures {
+		executor.Procedures[name] = procedure
+	}
+
+	// 共享选择器通过 {{selectors.名称}} 引用
+	if len(task.Selectors) > 0 {
+		selectors := make(map[string]interface{}, len(task.Selectors))
+		for name, selector := range task.Selectors {
+			selectors[name] = selector
+		}
+		executor.SetVariable(VariableSelectors, selectors)
+	}
+
+	// 记录任务初始页面的地址和标题
+	executor.recordPageInfo()
+
 	// 执行节点项序列
-	if err := executor.ExecuteNodeItems(items); err != nil {
-		return err
+	err := executor.ExecuteNodeItems(task.Actions)
+
+	// 输出重试策略下的每次尝试记录
+	for _, record := range executor.Context.Attempts {
+		if record.Success {
+			fmt.Printf("   🔁 %s 第%d次尝试成功\n", record.Target, record.Attempt)
+		} else {
+			fmt.Printf("   🔁 %s 第%d次尝试失败 [%s]: %s\n", record.Target, record.Attempt, record.Kind, record.Error)
+		}
 	}
-	
-	return nil
+
+	return executor.Context.Attempts, err
 }
 
 // ExecuteTasks 批量执行任务
@@ -158,21 +217,78 @@ func (tm *TaskManager) SaveTaskResults(results []logger.TaskResult, filename str
 	return logger.SaveTaskResults(results, filename)
 }
 
-// LoadTasksFromFile 从YAML文件加载任务配置
-func LoadTasksFromFile(filename string) ([]Task, error) {
+// LoadTasksFromFile 从YAML文件加载任务配置，libraries为额外的过程库文件
+func LoadTasksFromFile(filename string, libraries ...string) ([]Task, error) {
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("读取YAML文件失败: %w", err)
 	}
 
-	// 首先尝试直接解析为Task数组
-	var tasks []Task
-	if err := yaml.Unmarshal(content, &tasks); err != nil {
+	// 顶层为列表时直接解析为Task数组，为映射时按TaskFile解析
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("YAML解码失败: %w", err)
+	}
+
+	var file TaskFile
+	if len(document.Content) > 0 && document.Content[0].Kind == yaml.MappingNode {
+		if err := document.Decode(&file); err != nil {
+			return nil, fmt.Errorf("YAML解码失败: %w", err)
+		}
+	} else if err := document.Decode(&file.Tasks); err != nil {
 		return nil, fmt.Errorf("YAML解码失败: %w", err)
 	}
+	tasks := file.Tasks
+
+	// 加载imports导入的共享过程和选择器，当前文件的选择器优先
+	chain := []string{filename}
+	importedProcedures, selectors, err := loadImports(&file, filename, chain, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	for name, selector := range file.Selectors {
+		selectors[name] = selector
+	}
+	if err := expandProcedureIncludes(file.Procedures, filename, chain); err != nil {
+		return nil, err
+	}
+
+	// 收集过程库、导入文件和文件内共享的过程
+	procedures := make(map[string]*Procedure)
+	for _, library := range libraries {
+		libraryProcedures, err := LoadProceduresFromFile(library)
+		if err != nil {
+			return nil, err
+		}
+		if err := registerProcedures(procedures, libraryProcedures); err != nil {
+			return nil, fmt.Errorf("过程库 %s 无效: %w", library, err)
+		}
+	}
+	if err := registerProcedures(procedures, importedProcedures); err != nil {
+		return nil, err
+	}
+	if err := registerProcedures(procedures, file.Procedures); err != nil {
+		return nil, err
+	}
+	for _, procedure := range procedures {
+		if err := validateCalls(procedure.Actions, procedures); err != nil {
+			return nil, fmt.Errorf("过程 %s: %w", procedure.Name, err)
+		}
+		if err := compileNodeItems(procedure.Actions, "actions"); err != nil {
+			return nil, fmt.Errorf("过程 %s 表达式无效: %w", procedure.Name, err)
+		}
+	}
 
 	// 将每个任务中的Action数组转换为NodeItem数组
 	for i := range tasks {
+		// 展开include引入的任务片段
+		actions, err := expandIncludes(tasks[i].Actions, filename, chain)
+		if err != nil {
+			return nil, fmt.Errorf("任务 %s 配置无效: %w", tasks[i].Name, err)
+		}
+		tasks[i].Actions = actions
+		tasks[i].Selectors = selectors
+
 		if len(tasks[i].Actions) > 0 {
 			// 检查第一个元素是否是有效的NodeItem
 			// 如果不是有效的NodeItem，说明是直接的Action对象
@@ -187,6 +303,21 @@ func LoadTasksFromFile(filename string) ([]Task, error) {
 				tasks[i].Actions = nodeItems
 			}
 		}
+
+		// 加载时校验流程控制结构，避免执行到一半才发现配置错误
+		if err := ValidateNodeItems(tasks[i].Actions); err != nil {
+			return nil, fmt.Errorf("任务 %s 配置无效: %w", tasks[i].Name, err)
+		}
+
+		// 解析任务可调用的过程并验证调用参数
+		if err := resolveTaskProcedures(&tasks[i], procedures); err != nil {
+			return nil, fmt.Errorf("任务 %s 配置无效: %w", tasks[i].Name, err)
+		}
+
+		// 预先解析条件、循环参数和模板，语法错误在启动浏览器前报告，执行时复用解析结果
+		if err := compileNodeItems(tasks[i].Actions, "actions"); err != nil {
+			return nil, fmt.Errorf("任务 %s 表达式无效: %w", tasks[i].Name, err)
+		}
 	}
 
 	return tasks, nil
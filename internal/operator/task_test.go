@@ -0,0 +1,614 @@
+package operator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTaskFiles 将测试用的任务文件写入临时目录并返回目录路径，文件内容支持 "- trace: 表达式" 简写
+func writeTaskFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(expandTraceSteps(content)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadTasksFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string // 入口文件为 main.yaml
+		wantErr string            // 为空表示期望加载成功
+	}{
+		{
+			name: "片段中的include相对于片段所在目录",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - include: "fragments/login.yaml"
+`,
+				"fragments/login.yaml": `
+- include: "steps/fill.yaml"
+`,
+				"fragments/steps/fill.yaml": `
+- type: fill
+  selector: "#username"
+  value: "test"
+`,
+			},
+		},
+		{
+			name: "循环include",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - include: "a.yaml"
+`,
+				"a.yaml": `
+- include: "b.yaml"
+`,
+				"b.yaml": `
+- include: "a.yaml"
+`,
+			},
+			wantErr: "a.yaml -> ",
+		},
+		{
+			name: "循环import",
+			files: map[string]string{
+				"main.yaml": `
+imports: ["shared/common.yaml"]
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: click
+        selector: "#a"
+`,
+				"shared/common.yaml": `
+imports: ["../main.yaml"]
+`,
+			},
+			wantErr: "检测到循环引入",
+		},
+		{
+			name: "菱形import只加载一次",
+			files: map[string]string{
+				"main.yaml": `
+imports: ["b.yaml", "c.yaml"]
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: call
+        procedure: "shared"
+      - type: call
+        procedure: "fromB"
+`,
+				"b.yaml": `
+imports: ["lib/d.yaml"]
+procedures:
+  - name: "fromB"
+    actions:
+      - type: call
+        procedure: "shared"
+`,
+				"c.yaml": `
+imports: ["./lib/../lib/d.yaml"]
+`,
+				"lib/d.yaml": `
+selectors:
+  submit: "#submit"
+procedures:
+  - name: "shared"
+    actions:
+      - type: click
+        selector: "{{selectors.submit}}"
+`,
+			},
+		},
+		{
+			name: "导入文件不能包含任务",
+			files: map[string]string{
+				"main.yaml": `
+imports: ["common.yaml"]
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: click
+        selector: "#a"
+`,
+				"common.yaml": `
+tasks:
+  - name: "其他任务"
+    url: "about:blank"
+    actions:
+      - type: click
+        selector: "#a"
+`,
+			},
+			wantErr: "不能包含tasks",
+		},
+		{
+			name: "include的文件不存在",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - include: "missing.yaml"
+`,
+			},
+			wantErr: "include missing.yaml 失败",
+		},
+		{
+			name: "片段中的配置错误指出所在文件",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - include: "fragment.yaml"
+`,
+				"fragment.yaml": `
+- type: click
+  selector: "#a"
+- type: else
+  children:
+    - type: click
+      selector: "#b"
+`,
+			},
+			wantErr: "fragment.yaml:4",
+		},
+		{
+			name: "调用未定义的过程",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: call
+      procedure: "login"
+`,
+			},
+			wantErr: "调用了未定义的过程: login",
+		},
+		{
+			name: "缺少必填参数",
+			files: map[string]string{
+				"main.yaml": `
+procedures:
+  - name: "login"
+    params: ["username", "password"]
+    defaults:
+      password: "secret"
+    actions:
+      - type: fill
+        selector: "#username"
+        value: "{{username}}"
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: call
+        procedure: "login"
+        args:
+          password: "other"
+`,
+			},
+			wantErr: "缺少必填参数: username",
+		},
+		{
+			name: "传入未声明的参数",
+			files: map[string]string{
+				"main.yaml": `
+procedures:
+  - name: "logout"
+    actions:
+      - type: click
+        selector: "#logout"
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: call
+        procedure: "logout"
+        args:
+          force: true
+`,
+			},
+			wantErr: "传入了未声明的参数: force",
+		},
+		{
+			name: "映射不存在的返回变量",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: define
+      name: "greet"
+      returns: ["message"]
+      children:
+        - type: click
+          selector: "#a"
+    - type: call
+      procedure: "greet"
+      output:
+        text: "greeting"
+`,
+			},
+			wantErr: "没有返回变量 greeting",
+		},
+		{
+			name: "共享过程与define重名",
+			files: map[string]string{
+				"main.yaml": `
+procedures:
+  - name: "login"
+    actions:
+      - type: click
+        selector: "#login"
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: define
+        name: "login"
+        children:
+          - type: click
+            selector: "#other"
+`,
+			},
+			wantErr: "过程 login 重复定义",
+		},
+		{
+			name: "define嵌套在过程体中",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: define
+      name: "outer"
+      children:
+        - type: define
+          name: "inner"
+          children:
+            - type: click
+              selector: "#a"
+`,
+			},
+			wantErr: "define只能出现在任务操作序列的顶层",
+		},
+		{
+			name: "define嵌套在共享过程中",
+			files: map[string]string{
+				"main.yaml": `
+procedures:
+  - name: "outer"
+    actions:
+      - type: define
+        name: "inner"
+        children:
+          - type: click
+            selector: "#a"
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: call
+        procedure: "outer"
+`,
+			},
+			wantErr: "过程 outer 配置无效",
+		},
+		{
+			name: "define嵌套在控制节点中",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: if
+      condition: "true"
+      children:
+        - type: define
+          name: "inner"
+          children:
+            - type: click
+              selector: "#a"
+`,
+			},
+			wantErr: "define只能出现在任务操作序列的顶层",
+		},
+		{
+			name: "表达式语法错误在加载时报告",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: if
+      condition: "count >"
+      children:
+        - type: click
+          selector: "#a"
+`,
+			},
+			wantErr: "condition 'count >' 语法错误",
+		},
+		{
+			name: "片段中的表达式错误指出所在文件和行号",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: click
+      selector: "#a"
+    - include: "fragment.yaml"
+`,
+				"fragment.yaml": `
+- type: for
+  from: 1
+  to: 3
+  children:
+    - type: if
+      condition: "i >"
+      children:
+        - type: break
+`,
+			},
+			wantErr: "fragment.yaml:6 (actions[1].children[0]): condition 'i >' 语法错误",
+		},
+		{
+			name: "download保存到下载目录之外",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: download
+      selector: "#export"
+      save_as: "../report.pdf"
+`,
+			},
+			wantErr: "下载文件名不能超出下载目录: ../report.pdf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTaskFiles(t, tt.files)
+			_, err := LoadTasksFromFile(filepath.Join(dir, "main.yaml"))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("加载返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecuteLoadedTask(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string // 入口文件为 main.yaml，执行其中的第一个任务
+		want         []string
+		wantAttempts int // 任务结果中保存的尝试记录数
+		wantErr      string
+	}{
+		{
+			name: "过程的参数、默认值和返回变量",
+			files: map[string]string{
+				"main.yaml": `
+procedures:
+  - name: "greet"
+    params: ["name", "greeting", "punctuation"]
+    defaults:
+      punctuation: "!"
+    returns: ["greeting"]
+    actions:
+      - trace: greeting + ', ' + name + punctuation
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: call
+        procedure: "greet"
+        args:
+          name: "张三"
+          greeting: "你好"
+        output:
+          message: "greeting"
+      - trace: message
+`,
+			},
+			want: []string{"你好, 张三!", "你好"},
+		},
+		{
+			name: "过程看不到调用方的变量",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: define
+      name: "probe"
+      children:
+        - trace: i ?? 'hidden'
+    - type: for
+      from: 1
+      to: 1
+      children:
+        - type: call
+          procedure: "probe"
+        - trace: i
+`,
+			},
+			want: []string{"hidden", "1"},
+		},
+		{
+			name: "参数只有一个模板时保留原始类型",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: define
+      name: "count"
+      params: ["items"]
+      returns: ["items"]
+      children:
+        - trace: len(items)
+    - type: for
+      from: 1
+      to: 1
+      children:
+        - type: call
+          procedure: "count"
+          args:
+            items: "{{ [i, i + 1] }}"
+    - trace: items[1]
+`,
+			},
+			want: []string{"2", "2"},
+		},
+		{
+			name: "递归调用超过深度上限",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: define
+      name: "recurse"
+      children:
+        - type: call
+          procedure: "recurse"
+    - type: call
+      procedure: "recurse"
+`,
+			},
+			wantErr: "过程调用深度超过上限 32",
+		},
+		{
+			name: "过程中的break不会越过过程边界",
+			files: map[string]string{
+				"main.yaml": `
+- name: "任务"
+  url: "about:blank"
+  actions:
+    - type: define
+      name: "stop"
+      children:
+        - type: break
+    - type: for
+      from: 1
+      to: 2
+      children:
+        - type: call
+          procedure: "stop"
+        - trace: i
+`,
+			},
+			want: []string{"1", "2"},
+		},
+		{
+			name: "导入的过程可以使用共享选择器",
+			files: map[string]string{
+				"main.yaml": `
+imports: ["shared/common.yaml"]
+selectors:
+  submit: "#submit-btn"
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: call
+        procedure: "submitForm"
+`,
+				"shared/common.yaml": `
+selectors:
+  submit: "#default-submit"
+  username: "#username"
+procedures:
+  - name: "submitForm"
+    actions:
+      - trace: selectors.username, selectors.submit
+`,
+			},
+			want: []string{"#username:#submit-btn"},
+		},
+		{
+			name: "过程中的重试记录返回给任务结果",
+			files: map[string]string{
+				"main.yaml": `
+procedures:
+  - name: "submit"
+    actions:
+      - type: click
+        selector: "#submit"
+        retry: {attempts: 2, delay: 1}
+tasks:
+  - name: "任务"
+    url: "about:blank"
+    actions:
+      - type: call
+        procedure: "submit"
+`,
+			},
+			wantAttempts: 2,
+			wantErr:      "重试2次后仍然失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTaskFiles(t, tt.files)
+			tasks, err := LoadTasksFromFile(filepath.Join(dir, "main.yaml"))
+			if err != nil {
+				t.Fatalf("加载返回错误: %v", err)
+			}
+
+			resetTrace()
+			attempts, err := NewTaskManager(&BrowserManager{}).executeActions(tasks[0])
+			got := takeTrace()
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("执行返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("执行顺序 = %q，期望 %q", got, tt.want)
+			}
+			if len(attempts) != tt.wantAttempts {
+				t.Errorf("尝试记录数 = %d，期望 %d", len(attempts), tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 模板定界符。转义写法 \{{ 只在YAML的单引号或不加引号的字符串中原样保留反斜杠，
+// 双引号字符串中的 \{ 是非法转义，解析任务文件时就会报错，此时可改写为表达式 {{ '{{' }}
+const (
+	templateOpen   = "{{"
+	templateClose  = "}}"
+	templateEscape = `\{{` // 表示字面量 {{
+)
+
+// templateSegment 模板片段，为普通文本或 {{ }} 中的表达式
+type templateSegment struct {
+	text       string     // 普通文本，或表达式的源码
+	expression Expression // 已解析的表达式，为nil时片段是普通文本
+	err        error      // 表达式的语法错误，求值时变量名本身存在则不会报告
+}
+
+// compiledTemplate 已解析的模板
+type compiledTemplate []templateSegment
+
+// compiledTemplates 已解析的模板，按模板源码缓存，含语法错误的模板不缓存
+var compiledTemplates sync.Map // string -> compiledTemplate
+
+// RenderTemplate 渲染模板：{{ }} 中的内容按表达式求值，支持 | 过滤器，\{{ 表示字面量 {{；
+// 引用未定义的变量时返回错误
+func RenderTemplate(input string, ctx *ExecutionContext) (string, error) {
+	if !strings.Contains(input, templateOpen) {
+		return input, nil
+	}
+
+	template, err := compileTemplate(input)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	for _, segment := range template {
+		if !segment.isExpression() {
+			result.WriteString(segment.text)
+			continue
+		}
+		value, err := segment.evaluate(ctx)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(formatVariable(value))
+	}
+
+	return result.String(), nil
+}
+
+// renderTemplateValue 渲染模板值：整个字符串只有一个 {{ }} 时保留求值结果的原始类型（如列表、映射），否则按字符串渲染
+func renderTemplateValue(input string, ctx *ExecutionContext) (interface{}, error) {
+	if !strings.Contains(input, templateOpen) {
+		return input, nil
+	}
+
+	template, err := compileTemplate(input)
+	if err != nil {
+		return nil, err
+	}
+	if segment, ok := template.single(); ok {
+		return segment.evaluate(ctx)
+	}
+	return RenderTemplate(input, ctx)
+}
+
+// compileTemplate 将模板拆分为文本和表达式片段并缓存，相同的模板只解析一次
+func compileTemplate(input string) (compiledTemplate, error) {
+	if cached, ok := compiledTemplates.Load(input); ok {
+		return cached.(compiledTemplate), nil
+	}
+
+	var (
+		template compiledTemplate
+		text     strings.Builder
+		valid    = true
+	)
+	i := 0
+	for i < len(input) {
+		if strings.HasPrefix(input[i:], templateEscape) {
+			text.WriteString(templateOpen)
+			i += len(templateEscape)
+			continue
+		}
+		if !strings.HasPrefix(input[i:], templateOpen) {
+			text.WriteByte(input[i])
+			i++
+			continue
+		}
+
+		source, end, err := templateExpression(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if text.Len() > 0 {
+			template = append(template, templateSegment{text: text.String()})
+			text.Reset()
+		}
+		segment := templateSegment{text: source}
+		if source == "" {
+			segment.err = fmt.Errorf("表达式为空")
+		} else {
+			segment.expression, segment.err = CompileExpression(source)
+		}
+		valid = valid && segment.err == nil
+		template = append(template, segment)
+		i = end
+	}
+	if text.Len() > 0 {
+		template = append(template, templateSegment{text: text.String()})
+	}
+
+	if valid {
+		compiledTemplates.Store(input, template)
+	}
+	return template, nil
+}
+
+// validateTemplate 检查模板中的表达式是否都能解析
+func validateTemplate(input string) error {
+	template, err := compileTemplate(input)
+	if err != nil {
+		return err
+	}
+	for _, segment := range template {
+		if segment.err != nil {
+			return fmt.Errorf("{{%s}}: %w", segment.text, segment.err)
+		}
+	}
+	return nil
+}
+
+// single 模板除首尾空白外只有一个表达式时返回该表达式片段
+func (t compiledTemplate) single() (templateSegment, bool) {
+	var found *templateSegment
+	for i := range t {
+		segment := &t[i]
+		if !segment.isExpression() {
+			if strings.TrimSpace(segment.text) != "" {
+				return templateSegment{}, false
+			}
+			continue
+		}
+		if found != nil {
+			return templateSegment{}, false
+		}
+		found = segment
+	}
+	if found == nil {
+		return templateSegment{}, false
+	}
+	return *found, true
+}
+
+// isExpression 检查片段是否为 {{ }} 表达式
+func (s templateSegment) isExpression() bool {
+	return s.expression != nil || s.err != nil
+}
+
+// evaluate 求值模板表达式，变量名本身包含点号等字符时优先按变量名查找
+func (s templateSegment) evaluate(ctx *ExecutionContext) (interface{}, error) {
+	if value, exists := ctx.LookupVariable(s.text); exists {
+		return value, nil
+	}
+	if s.err != nil {
+		return nil, fmt.Errorf("模板 {{%s}} 求值失败: %w", s.text, s.err)
+	}
+
+	value, err := s.expression.Evaluate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("模板 {{%s}} 求值失败: %w", s.text, err)
+	}
+	return value, nil
+}
+
+// templateExpression 提取从start处的 {{ 开始的模板表达式，返回表达式内容和 }} 之后的位置；
+// 引号内和花括号内的 }} 不会被当作结束符
+func templateExpression(input string, start int) (string, int, error) {
+	var quote byte
+	depth := 0
+	for i := start + len(templateOpen); i < len(input); i++ {
+		ch := input[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case depth == 0 && strings.HasPrefix(input[i:], templateClose):
+			return strings.TrimSpace(input[start+len(templateOpen) : i]), i + len(templateClose), nil
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+		}
+	}
+	return "", 0, fmt.Errorf("模板 '%s' 缺少结束符 %s，字面量 %s 请写作 %s", input[start:], templateClose, templateOpen, templateEscape)
+}
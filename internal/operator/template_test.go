@@ -0,0 +1,48 @@
+package operator
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderTemplateEscape(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string // 任务文件中value字段的写法
+		want    string
+		wantErr string // YAML解析错误
+	}{
+		{name: "单引号中的转义", yaml: `value: '\{{name}} = {{name}}'`, want: "{{name}} = 张三"},
+		{name: "不加引号的转义", yaml: `value: \{{name}} = {{name}}`, want: "{{name}} = 张三"},
+		{name: "双引号中用表达式输出定界符", yaml: `value: "{{ '{{' }}name}} = {{name}}"`, want: "{{name}} = 张三"},
+		{name: "双引号中的反斜杠是非法转义", yaml: `value: "\{{name}}"`, wantErr: "found unknown escape character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var action Action
+			err := yaml.Unmarshal([]byte(tt.yaml), &action)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析YAML失败: %v", err)
+			}
+
+			ctx := NewExecutionContext()
+			ctx.SetVariable("name", "张三")
+			got, err := RenderTemplate(action.Value, ctx)
+			if err != nil {
+				t.Fatalf("渲染模板失败: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q，期望 %q", action.Value, got, tt.want)
+			}
+		})
+	}
+}
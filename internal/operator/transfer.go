@@ -0,0 +1,110 @@
+package operator
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultDownloadsDir 未配置下载目录时保存下载文件的目录
+const DefaultDownloadsDir = "downloads"
+
+// validateTransfer 验证上传和下载操作的参数，不含模板的上传文件在加载时检查是否存在
+func validateTransfer(action *Action) error {
+	if action.Type != ActionUpload && len(action.Files) > 0 {
+		return fmt.Errorf("%s操作不支持files参数", action.Type)
+	}
+	if action.Type != ActionDownload && action.SaveAs != "" {
+		return fmt.Errorf("%s操作不支持save_as参数", action.Type)
+	}
+
+	switch action.Type {
+	case ActionUpload:
+		if len(action.Files) == 0 {
+			return fmt.Errorf("upload操作需要提供files参数")
+		}
+		for _, file := range action.Files {
+			if strings.Contains(file, templateOpen) {
+				continue
+			}
+			if _, err := uploadPath(action.Source, file); err != nil {
+				return err
+			}
+		}
+	case ActionDownload:
+		if action.Selector == "" {
+			return fmt.Errorf("download操作需要提供selector参数，指定点击后触发下载的元素")
+		}
+		if action.SaveAs != "" && !strings.Contains(action.SaveAs, templateOpen) {
+			if _, err := downloadPath(DefaultDownloadsDir, action.SaveAs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// uploadPath 解析上传文件的路径，相对路径基于操作所在的任务文件所在目录，并检查文件是否存在
+func uploadPath(source, file string) (string, error) {
+	path := file
+	if source != "" {
+		path = resolveRelativePath(source, file)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("上传文件不存在: %s", path)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("上传文件不能是目录: %s", path)
+	}
+	return path, nil
+}
+
+// downloadPath 解析下载文件的保存路径，文件名只能是下载目录内的相对路径
+func downloadPath(dir, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("下载文件名不能是绝对路径: %s", name)
+	}
+	path := filepath.Join(dir, name)
+	rel, err := filepath.Rel(filepath.Clean(dir), path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("下载文件名不能超出下载目录: %s", name)
+	}
+	return path, nil
+}
+
+// uploadFiles 执行upload操作，文件路径支持{{var}}模板
+func (ce *ControlExecutor) uploadFiles(action *Action, selector string) error {
+	files := make([]string, 0, len(action.Files))
+	for _, file := range action.Files {
+		rendered, err := ce.replaceVariables(file)
+		if err != nil {
+			return fmt.Errorf("upload操作的files模板替换失败: %w", err)
+		}
+		path, err := uploadPath(action.Source, rendered)
+		if err != nil {
+			return err
+		}
+		files = append(files, path)
+	}
+
+	return ce.TaskManager.BrowserManager.UploadFiles(selector, files)
+}
+
+// downloadFile 执行download操作，下载完成后将保存路径存入output_key
+func (ce *ControlExecutor) downloadFile(action *Action, selector, saveAs string) error {
+	path, err := ce.TaskManager.BrowserManager.Download(selector, saveAs, time.Duration(action.Timeout)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	if action.OutputKey != "" {
+		ce.Context.SetVariable(action.OutputKey, path)
+		log.Printf("📋 下载文件路径已存储到变量: %s", action.OutputKey)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package operator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadPath(t *testing.T) {
+	dir := filepath.Join("downloads", "run")
+	tests := []struct {
+		name    string
+		file    string
+		want    string
+		wantErr string
+	}{
+		{name: "文件名", file: "report.pdf", want: filepath.Join(dir, "report.pdf")},
+		{name: "子目录", file: "2024/report.pdf", want: filepath.Join(dir, "2024", "report.pdf")},
+		{name: "清理后仍在下载目录内", file: "a/../report.pdf", want: filepath.Join(dir, "report.pdf")},
+		{name: "绝对路径", file: "/etc/passwd", wantErr: "下载文件名不能是绝对路径"},
+		{name: "跳出下载目录", file: "../../.bashrc", wantErr: "下载文件名不能超出下载目录"},
+		{name: "清理后跳出下载目录", file: "a/../../report.pdf", wantErr: "下载文件名不能超出下载目录"},
+		{name: "指向下载目录本身", file: ".", wantErr: "下载文件名不能超出下载目录"},
+		{name: "以..开头的文件名", file: "..report.pdf", want: filepath.Join(dir, "..report.pdf")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := downloadPath(dir, tt.file)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("返回错误: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("downloadPath(%q) = %q，期望 %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
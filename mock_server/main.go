@@ -111,6 +111,13 @@ func main() {
 		})
 	})
 
+	// 文件上传下载页面路由
+	r.GET("/file-transfer", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "file_transfer.html", gin.H{
+			"title": "文件上传下载 - Auto-Go Mock Server",
+		})
+	})
+
 	// API 路由：获取当前时间
 	r.GET("/api/time", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -170,6 +177,57 @@ func main() {
 		})
 	})
 
+	// 文件上传路由：接收multipart表单中的files字段，返回文件名和大小
+	r.POST("/upload", func(c *gin.Context) {
+		form, err := c.MultipartForm()
+		if err != nil || len(form.File["files"]) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "没有收到上传的文件",
+			})
+			return
+		}
+
+		files := make([]gin.H, 0, len(form.File["files"]))
+		for _, file := range form.File["files"] {
+			log.Printf("收到上传文件: %s (%d 字节)", file.Filename, file.Size)
+			files = append(files, gin.H{
+				"name": file.Filename,
+				"size": file.Size,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": fmt.Sprintf("成功上传 %d 个文件", len(files)),
+			"files":   files,
+		})
+	})
+
+	// 文件下载路由：以附件形式返回固定内容，触发浏览器下载
+	r.GET("/download/:name", func(c *gin.Context) {
+		name := c.Param("name")
+		downloads := map[string]struct {
+			contentType string
+			content     string
+		}{
+			"report.csv": {"text/csv; charset=utf-8", "日期,订单数,金额\n2024-01-01,12,4799\n2024-01-02,8,3200\n"},
+			"notes.txt":  {"text/plain; charset=utf-8", "Auto-Go 下载测试文件\n"},
+		}
+
+		file, ok := downloads[name]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "文件不存在",
+			})
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		c.Data(http.StatusOK, file.contentType, []byte(file.content))
+	})
+
 	// 简单表单提交路由
 	r.POST("/submit-form", func(c *gin.Context) {
 		// 模拟表单处理
@@ -220,6 +278,7 @@ func main() {
 	fmt.Printf("  - 表单页面: http://localhost:%d/form-page\n", port)
 	fmt.Printf("  - 商品目录: http://localhost:%d/catalog\n", port)
 	fmt.Printf("  - 控制面板: http://localhost:%d/dashboard\n", port)
+	fmt.Printf("  - 文件上传下载: http://localhost:%d/file-transfer\n", port)
 	fmt.Printf("按 Ctrl+C 停止服务器")
 
 	// 启动 HTTP 服务器